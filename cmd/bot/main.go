@@ -7,7 +7,6 @@ import (
 	"syscall"
 
 	"tgbot/internal/bot"
-	"tgbot/internal/services/monitoring"
 	"tgbot/internal/services/system"
 	"tgbot/pkg/config"
 
@@ -27,24 +26,14 @@ func main() {
 	}
 
 	// Создание системного монитора
-	systemMonitor := system.NewMonitor()
+	systemMonitor := system.NewMonitor(cfg.Storage.DataDir)
 
 	// Создание бота
-	b, err := bot.NewBot(cfg)
+	b, err := bot.NewBot(cfg, systemMonitor)
 	if err != nil {
 		log.Fatalf("Ошибка создания бота: %v", err)
 	}
 
-	// Создание и запуск сервиса мониторинга
-	// Используем первый разрешенный чат для отправки уведомлений
-	var monitoringChatID int64
-	if len(cfg.Bot.AllowedChats) > 0 {
-		monitoringChatID = cfg.Bot.AllowedChats[0]
-	}
-
-	monitoringService := monitoring.NewService(b.GetAPI(), cfg, systemMonitor, monitoringChatID)
-	monitoringService.Start()
-
 	// Запуск бота
 	go func() {
 		if err := b.Start(); err != nil {
@@ -57,9 +46,6 @@ func main() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
 
-	// Остановка сервиса мониторинга
-	monitoringService.Stop()
-
 	// Остановка бота
 	b.Stop()
 	log.Println("Бот остановлен")
@@ -71,6 +57,32 @@ func initConfig() error {
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(".")
 
+	// Значения по умолчанию для параметров, которых может не быть в существующем файле
+	viper.SetDefault("storage.data_dir", "data")
+	viper.SetDefault("maintenance.reboot_time", "03:00")
+	viper.SetDefault("monitoring.alert_duration", 60)
+	viper.SetDefault("monitoring.repeat_interval", 3600)
+	viper.SetDefault("monitoring.inode_threshold", 90)
+	viper.SetDefault("metrics.enabled", true)
+	viper.SetDefault("metrics.interval", 10)
+	viper.SetDefault("metrics.raw_retention", 24)
+	viper.SetDefault("metrics.rollup_interval", 300)
+	viper.SetDefault("metrics.rollup_retention", 30)
+	viper.SetDefault("exporter.listen", "127.0.0.1:9101")
+	viper.SetDefault("exporter.path", "/metrics")
+	viper.SetDefault("traffic.direction", "both")
+	viper.SetDefault("traffic.reset_day", 1)
+	viper.SetDefault("cleanup.journal_size", 500)
+	viper.SetDefault("cleanup.log_age", 7)
+	viper.SetDefault("kernel_log.enabled", true)
+	viper.SetDefault("kernel_log.cooldown", 600)
+	viper.SetDefault("auth_log.enabled", true)
+	viper.SetDefault("auth_log.sudo", true)
+	viper.SetDefault("auth_log.failed_threshold", 5)
+	viper.SetDefault("auth_log.failed_window", 300)
+	viper.SetDefault("auth_log.cooldown", 600)
+	viper.SetDefault("fail2ban.digest", true)
+
 	// Чтение конфигурации
 	if err := viper.ReadInConfig(); err != nil {
 		// Если файл конфигурации не найден, создаем его с значениями по умолчанию
@@ -5,6 +5,7 @@ go 1.19
 require (
 	github.com/docker/docker v24.0.5+incompatible
 	github.com/go-telegram-bot-api/telegram-bot-api v4.6.4+incompatible
+	github.com/godbus/dbus/v5 v5.1.0
 	github.com/shirou/gopsutil/v3 v3.23.9
 	github.com/spf13/viper v1.16.0
 )
@@ -16,7 +17,6 @@ require (
 	github.com/docker/go-units v0.5.0 // indirect
 	github.com/fsnotify/fsnotify v1.6.0 // indirect
 	github.com/go-ole/go-ole v1.2.6 // indirect
-	github.com/godbus/dbus/v5 v5.1.0 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/hashicorp/hcl v1.0.0 // indirect
 	github.com/lufia/plan9stats v0.0.0-20211012122336-39d0f177ccd0 // indirect
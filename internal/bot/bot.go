@@ -2,10 +2,22 @@ package bot
 
 import (
 	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"tgbot/internal/handlers"
 	"tgbot/internal/services/docker"
+	"tgbot/internal/services/exporter"
+	"tgbot/internal/services/logwatch"
+	"tgbot/internal/services/maintenance"
+	"tgbot/internal/services/metrics"
+	"tgbot/internal/services/monitoring"
+	"tgbot/internal/services/notify"
+	"tgbot/internal/services/report"
 	"tgbot/internal/services/system"
+	"tgbot/internal/services/traffic"
 	"tgbot/pkg/config"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
@@ -18,12 +30,23 @@ type Bot struct {
 	commandHandler *handlers.CommandHandler
 	systemService  *system.Monitor
 	dockerService  *docker.Manager
+	scheduler      *maintenance.Scheduler
+	startupReport  *report.StartupReporter
+	monitoring     *monitoring.Service
+	collector      *metrics.Collector
+	digester       *report.Digester
+	traffic        *traffic.Accountant
+	kernelWatcher  *logwatch.KernelWatcher
+	authWatcher    *logwatch.AuthWatcher
+	exporter       *exporter.Exporter
+	commandStats   *exporter.CommandStats
 }
 
 // NewBot создает нового бота
-func NewBot(cfg *config.Config) (*Bot, error) {
-	// Создание API клиента
-	api, err := tgbotapi.NewBotAPI(cfg.Bot.Token)
+func NewBot(cfg *config.Config, systemService *system.Monitor) (*Bot, error) {
+	// Создание API клиента; транспорт подсчитывает запросы к Telegram API для экспортера метрик
+	apiStats := exporter.NewAPIStats(nil)
+	api, err := tgbotapi.NewBotAPIWithClient(cfg.Bot.Token, &http.Client{Transport: apiStats})
 	if err != nil {
 		return nil, err
 	}
@@ -32,14 +55,52 @@ func NewBot(cfg *config.Config) (*Bot, error) {
 	log.Printf("Авторизован как %s", api.Self.UserName)
 
 	// Создание сервисов
-	systemService := system.NewMonitor()
 	dockerService, err := docker.NewManager(cfg.Docker.Socket)
 	if err != nil {
 		return nil, err
 	}
 
+	scheduler := maintenance.NewScheduler(api, systemService, cfg.Storage.DataDir)
+	startupReport := report.NewStartupReporter(api, systemService, dockerService, scheduler, cfg.Bot.NotificationChatID(), cfg.Storage.DataDir)
+
+	// История метрик необязательна: при ошибке бот работает без неё
+	var collector *metrics.Collector
+	if cfg.Metrics.Enabled {
+		collector, err = newMetricsCollector(cfg, systemService)
+		if err != nil {
+			log.Printf("Ошибка создания хранилища метрик: %v", err)
+		}
+	}
+
+	var metricsStore *metrics.Store
+	if collector != nil {
+		metricsStore = collector.Store()
+	}
+
+	trafficAccountant := traffic.NewAccountant(cfg, systemService)
+
+	// Уведомления без подходящего маршрута отправляются в первый разрешенный чат
+	router := notify.NewRouter(api, cfg.Notifications, cfg.Bot.NotificationChatID())
+	monitoringService := monitoring.NewService(router, cfg, systemService, dockerService, metricsStore, trafficAccountant)
+	digester := report.NewDigester(router, cfg, systemService, dockerService, monitoringService, metricsStore)
+
+	var kernelWatcher *logwatch.KernelWatcher
+	if cfg.KernelLog.Enabled {
+		kernelWatcher = logwatch.NewKernelWatcher(router, cfg.KernelLog, systemService, dockerService)
+	}
+	var authWatcher *logwatch.AuthWatcher
+	if cfg.AuthLog.Enabled {
+		authWatcher = logwatch.NewAuthWatcher(router, cfg.AuthLog)
+	}
+
 	// Создание обработчика команд
-	commandHandler := handlers.NewCommandHandler(api, systemService, dockerService)
+	commandHandler := handlers.NewCommandHandler(api, cfg, systemService, dockerService, scheduler, monitoringService, metricsStore, trafficAccountant)
+
+	commandStats := exporter.NewCommandStats()
+	var metricsExporter *exporter.Exporter
+	if cfg.Exporter.Enabled {
+		metricsExporter = exporter.NewExporter(cfg.Exporter, systemService, dockerService, monitoringService, apiStats, commandStats)
+	}
 
 	return &Bot{
 		api:            api,
@@ -47,11 +108,53 @@ func NewBot(cfg *config.Config) (*Bot, error) {
 		commandHandler: commandHandler,
 		systemService:  systemService,
 		dockerService:  dockerService,
+		scheduler:      scheduler,
+		startupReport:  startupReport,
+		monitoring:     monitoringService,
+		collector:      collector,
+		digester:       digester,
+		traffic:        trafficAccountant,
+		kernelWatcher:  kernelWatcher,
+		authWatcher:    authWatcher,
+		exporter:       metricsExporter,
+		commandStats:   commandStats,
 	}, nil
 }
 
+// newMetricsCollector создает сборщик истории метрик с хранилищем в директории данных
+func newMetricsCollector(cfg *config.Config, systemService *system.Monitor) (*metrics.Collector, error) {
+	interval := time.Duration(cfg.Metrics.Interval) * time.Second
+	store, err := metrics.NewStore(filepath.Join(cfg.Storage.DataDir, "metrics"), []metrics.Tier{
+		{Name: "raw", Step: interval, Retention: time.Duration(cfg.Metrics.RawRetention) * time.Hour},
+		{Name: "rollup", Step: time.Duration(cfg.Metrics.RollupInterval) * time.Second, Retention: time.Duration(cfg.Metrics.RollupRetention) * 24 * time.Hour},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metrics.NewCollector(store, systemService, interval), nil
+}
+
 // Start запускает бота
 func (b *Bot) Start() error {
+	// Отчет о запуске, запуск плановых перезагрузок, учета трафика, мониторинга, журналов ядра и авторизации, сбора метрик, сводок и экспортера
+	b.startupReport.Start()
+	b.scheduler.Start()
+	b.traffic.Start()
+	b.monitoring.Start()
+	if b.kernelWatcher != nil {
+		b.kernelWatcher.Start()
+	}
+	if b.authWatcher != nil {
+		b.authWatcher.Start()
+	}
+	if b.collector != nil {
+		b.collector.Start()
+	}
+	b.digester.Start()
+	if b.exporter != nil {
+		b.exporter.Start()
+	}
+
 	// Настройка получения обновлений
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = b.config.Bot.UpdateTimeout
@@ -71,15 +174,19 @@ func (b *Bot) Start() error {
 			}
 
 			// Обработка команд
+			started := time.Now()
 			b.commandHandler.HandleCommand(update)
+			b.commandStats.Observe("message", time.Since(started))
 		} else if update.CallbackQuery != nil {
-			// Проверка авторизации
-			if !b.isAuthorized(update.CallbackQuery.Message.Chat.ID) {
+			// Проверка авторизации; кнопки алертов доступны и в чатах каналов
+			if !b.isAuthorizedCallback(update.CallbackQuery) {
 				continue
 			}
 
 			// Обработка callback запросов
+			started := time.Now()
 			b.handleCallback(update)
+			b.commandStats.Observe("callback", time.Since(started))
 		}
 	}
 
@@ -90,6 +197,26 @@ func (b *Bot) Start() error {
 func (b *Bot) Stop() {
 	// Закрытие канала обновлений
 	b.api.StopReceivingUpdates()
+
+	if b.exporter != nil {
+		b.exporter.Stop()
+	}
+
+	// Остановка журналов ядра и авторизации, мониторинга, сводок, сбора метрик, учета трафика, плановых перезагрузок и отметка о штатном завершении
+	if b.kernelWatcher != nil {
+		b.kernelWatcher.Stop()
+	}
+	if b.authWatcher != nil {
+		b.authWatcher.Stop()
+	}
+	b.monitoring.Stop()
+	b.digester.Stop()
+	if b.collector != nil {
+		b.collector.Stop()
+	}
+	b.traffic.Stop()
+	b.scheduler.Stop()
+	b.startupReport.Stop()
 }
 
 // GetAPI возвращает API клиента бота
@@ -107,6 +234,36 @@ func (b *Bot) isAuthorized(chatID int64) bool {
 	return false
 }
 
+// channelCallbackPrefixes кнопки, доступные в чатах каналов уведомлений: только подтверждение и отключение алертов
+var channelCallbackPrefixes = []string{"alert_ack:", "alert_silence:", "alert_unsilence:"}
+
+// isAuthorizedCallback проверяет, можно ли обработать нажатие кнопки в чате
+// Алерты с кнопками подтверждения и отключения доставляются в чаты каналов уведомлений,
+// поэтому в них обрабатываются только кнопки алертов; управление сервером доступно лишь в разрешенных чатах
+func (b *Bot) isAuthorizedCallback(callback *tgbotapi.CallbackQuery) bool {
+	chatID := callback.Message.Chat.ID
+	if b.isAuthorized(chatID) {
+		return true
+	}
+
+	alertAction := false
+	for _, prefix := range channelCallbackPrefixes {
+		if strings.HasPrefix(callback.Data, prefix) {
+			alertAction = true
+			break
+		}
+	}
+	if !alertAction {
+		return false
+	}
+	for _, channel := range b.config.Notifications.Channels {
+		if channel.ChatID == chatID {
+			return true
+		}
+	}
+	return false
+}
+
 // handleCallback обрабатывает callback запросы
 func (b *Bot) handleCallback(update tgbotapi.Update) {
 	// Передаем обработку всех callback-запросов в commandHandler
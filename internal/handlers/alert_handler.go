@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tgbot/internal/services/monitoring"
+	"tgbot/pkg/schedule"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// handleSilences показывает активные заглушки алертов
+func (h *CommandHandler) handleSilences(chatID int64) {
+	silences := h.monitoring.Silences()
+	if len(silences) == 0 {
+		msg := tgbotapi.NewMessage(chatID, "🔔 Активных заглушек нет")
+		h.bot.Send(msg)
+		return
+	}
+
+	message := fmt.Sprintf("🔕 Активные заглушки (%d):\n\n", len(silences))
+	buttons := make([][]tgbotapi.InlineKeyboardButton, 0, len(silences))
+	for _, silence := range silences {
+		message += fmt.Sprintf("• %s — до %s (осталось %s)", silence.Key,
+			silence.Until.Format("2006-01-02 15:04"), schedule.FormatDuration(time.Until(silence.Until)))
+		if silence.CreatedBy != "" {
+			message += fmt.Sprintf(", %s", silence.CreatedBy)
+		}
+		message += "\n"
+
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔔 Снять: "+silence.Key, "alert_unsilence:"+monitoring.AlertID(silence.Key)),
+		))
+	}
+
+	// Без ParseMode, так как ключи алертов могут содержать символы разметки
+	msg := tgbotapi.NewMessage(chatID, message)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	h.bot.Send(msg)
+}
+
+// handleAlertAck подтверждает алерт
+func (h *CommandHandler) handleAlertAck(callback *tgbotapi.CallbackQuery, id string) {
+	var message string
+	key, err := h.monitoring.Acknowledge(id, userName(callback.From))
+	if err != nil {
+		message = fmt.Sprintf("ℹ️ Не удалось подтвердить алерт: %v", err)
+	} else {
+		message = fmt.Sprintf("👌 Алерт %s подтвержден (%s), напоминания отключены до возврата в норму", key, userName(callback.From))
+	}
+
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID, message)
+	h.bot.Send(msg)
+}
+
+// handleAlertSilence заглушает алерт; данные имеют вид "<часы>:<id>"
+func (h *CommandHandler) handleAlertSilence(callback *tgbotapi.CallbackQuery, data string) {
+	parts := strings.SplitN(data, ":", 2)
+	hours, err := strconv.Atoi(parts[0])
+	if len(parts) != 2 || err != nil || hours <= 0 {
+		msg := tgbotapi.NewMessage(callback.Message.Chat.ID, "❌ Некорректная длительность заглушки")
+		h.bot.Send(msg)
+		return
+	}
+
+	var message string
+	silence, err := h.monitoring.Silence(parts[1], time.Duration(hours)*time.Hour, userName(callback.From))
+	if err != nil {
+		message = fmt.Sprintf("❌ Ошибка заглушки алерта: %v", err)
+	} else {
+		message = fmt.Sprintf("🔕 Уведомления по алерту %s отключены до %s", silence.Key, silence.Until.Format("2006-01-02 15:04"))
+	}
+
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID, message)
+	h.bot.Send(msg)
+}
+
+// handleAlertUnsilence снимает заглушку с алерта
+func (h *CommandHandler) handleAlertUnsilence(callback *tgbotapi.CallbackQuery, id string) {
+	message := "ℹ️ Заглушка не найдена или уже истекла"
+	if key, ok := h.monitoring.Unsilence(id); ok {
+		message = fmt.Sprintf("🔔 Уведомления по алерту %s снова включены", key)
+	}
+
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID, message)
+	h.bot.Send(msg)
+}
+
+// userName возвращает имя пользователя Telegram для журнала действий
+func userName(user *tgbotapi.User) string {
+	if user == nil {
+		return ""
+	}
+	if user.UserName != "" {
+		return "@" + user.UserName
+	}
+	return strings.TrimSpace(user.FirstName + " " + user.LastName)
+}
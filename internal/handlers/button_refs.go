@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// buttonRefTTL время, после которого кнопка со ссылкой считается устаревшей
+const buttonRefTTL = 24 * time.Hour
+
+// maxButtonRefs количество запоминаемых ссылок; при превышении удаляются самые старые
+const maxButtonRefs = 1000
+
+// buttonRef значение, на которое ссылается кнопка
+type buttonRef struct {
+	value   string
+	created time.Time
+}
+
+// buttonRefs короткие идентификаторы для значений, не умещающихся в 64 байта callback-данных Telegram
+// Идентификаторы не повторяются, поэтому устаревшая кнопка не может сослаться на другое значение:
+// после удаления ссылки она просто перестает работать
+type buttonRefs struct {
+	refs map[string]buttonRef
+	// next следующий номер; начинается со времени запуска, чтобы кнопки прошлого запуска бота не совпали с новыми
+	next uint64
+	mu   sync.Mutex
+}
+
+// newButtonRefs создает хранилище ссылок кнопок
+func newButtonRefs() *buttonRefs {
+	return &buttonRefs{
+		refs: make(map[string]buttonRef),
+		next: uint64(time.Now().UnixNano()),
+	}
+}
+
+// Add запоминает значение и возвращает идентификатор для callback-данных
+func (b *buttonRefs) Add(value string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.expire(now)
+
+	id := strconv.FormatUint(b.next, 36)
+	b.next++
+	b.refs[id] = buttonRef{value: value, created: now}
+	return id
+}
+
+// Get возвращает значение по идентификатору из кнопки
+func (b *buttonRefs) Get(id string) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ref, ok := b.refs[id]
+	if !ok || time.Since(ref.created) > buttonRefTTL {
+		return "", false
+	}
+	return ref.value, true
+}
+
+// expire удаляет устаревшие ссылки, а при превышении maxButtonRefs - самые старые
+// Вызывается с захваченной блокировкой
+func (b *buttonRefs) expire(now time.Time) {
+	for id, ref := range b.refs {
+		if now.Sub(ref.created) > buttonRefTTL {
+			delete(b.refs, id)
+		}
+	}
+	for len(b.refs) >= maxButtonRefs {
+		oldestID := ""
+		var oldest time.Time
+		for id, ref := range b.refs {
+			if oldestID == "" || ref.created.Before(oldest) {
+				oldestID, oldest = id, ref.created
+			}
+		}
+		delete(b.refs, oldestID)
+	}
+}
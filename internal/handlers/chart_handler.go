@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"tgbot/internal/services/metrics"
+	"tgbot/internal/services/notify"
+	"tgbot/pkg/chart"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// chartPeriods доступные периоды графиков
+var chartPeriods = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// chartUsage подсказка по команде /chart
+const chartUsage = "ℹ️ Использование: /chart cpu|ram|disk|net [1h|24h|7d]"
+
+// maxChartSeries количество рядов на графике по числу цветов палитры; остальные ряды с меньшим средним не выводятся
+const maxChartSeries = 8
+
+// maxCaptionLength ограничение Telegram на длину подписи к изображению
+const maxCaptionLength = 1024
+
+// chartSeries ряд истории метрик для графика
+type chartSeries struct {
+	name   string
+	series string
+}
+
+// chartLine ряд графика с показателями для подписи
+type chartLine struct {
+	series                  chart.Series
+	minValue, avg, maxValue float64
+}
+
+// handleChart строит график истории метрики и отправляет его изображением
+func (h *CommandHandler) handleChart(chatID int64, args []string) {
+	if h.metricsStore == nil {
+		msg := tgbotapi.NewMessage(chatID, "📉 История метрик отключена (параметр metrics.enabled)")
+		h.bot.Send(msg)
+		return
+	}
+	if len(args) == 0 || len(args) > 2 {
+		msg := tgbotapi.NewMessage(chatID, chartUsage)
+		h.bot.Send(msg)
+		return
+	}
+
+	periodName := "24h"
+	if len(args) == 2 {
+		periodName = args[1]
+	}
+	period, ok := chartPeriods[periodName]
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, chartUsage)
+		h.bot.Send(msg)
+		return
+	}
+
+	to := time.Now()
+	c := chart.Chart{From: to.Add(-period), To: to, TimeFormat: "15:04"}
+	if period > 24*time.Hour {
+		c.TimeFormat = "02.01"
+	}
+
+	var title, unit string
+	var sources []chartSeries
+	switch strings.ToLower(args[0]) {
+	case "cpu":
+		title, unit = "CPU", "%"
+		sources = []chartSeries{{name: "cpu", series: metrics.SeriesCPU}}
+		c.Thresholds = h.chartThresholds("cpu", false)
+	case "ram":
+		title, unit = "RAM", "%"
+		sources = []chartSeries{{name: "ram", series: metrics.SeriesMemory}, {name: "swap", series: metrics.SeriesSwap}}
+		c.Thresholds = h.chartThresholds("memory", false)
+	case "disk":
+		title, unit = "Диски", "%"
+		sources = h.chartSeriesByPrefix(metrics.SeriesDisk, "")
+		// Пороги свободного места переводятся в занятое, так как на графике отображается заполнение
+		c.Thresholds = append(h.chartThresholds("disk_used", false), h.chartThresholds("disk_free", true)...)
+	case "net":
+		title = "Сеть"
+		sources = append(h.chartSeriesByPrefix(metrics.SeriesNetRx, " rx"), h.chartSeriesByPrefix(metrics.SeriesNetTx, " tx")...)
+		sources = h.currentInterfaceSeries(sources)
+		c.FormatValue = formatRate
+	default:
+		msg := tgbotapi.NewMessage(chatID, chartUsage)
+		h.bot.Send(msg)
+		return
+	}
+
+	if unit == "%" {
+		c.YMin, c.YMax = 0, 100
+		c.FormatValue = func(v float64) string { return fmt.Sprintf("%.0f%%", v) }
+	}
+
+	var lines []chartLine
+	for _, source := range sources {
+		points, err := h.metricsStore.Query(source.series, c.From, c.To)
+		if err != nil {
+			msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Ошибка чтения истории метрик: %v", err))
+			h.bot.Send(msg)
+			return
+		}
+		if len(points) == 0 {
+			continue
+		}
+
+		series := chart.Series{Name: source.name, Points: make([]chart.Point, 0, len(points))}
+		minValue, maxValue, sum := points[0].Value, points[0].Value, 0.0
+		for _, point := range points {
+			series.Points = append(series.Points, chart.Point(point))
+			if point.Value < minValue {
+				minValue = point.Value
+			}
+			if point.Value > maxValue {
+				maxValue = point.Value
+			}
+			sum += point.Value
+		}
+		lines = append(lines, chartLine{series: series, minValue: minValue, avg: sum / float64(len(points)), maxValue: maxValue})
+	}
+
+	if len(lines) == 0 {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("📉 Нет данных за %s", periodName))
+		h.bot.Send(msg)
+		return
+	}
+
+	// Цвета палитры повторяются, поэтому выводятся ряды с наибольшим средним в исходном порядке
+	hidden := 0
+	if len(lines) > maxChartSeries {
+		hidden = len(lines) - maxChartSeries
+		order := make([]int, len(lines))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool { return lines[order[i]].avg > lines[order[j]].avg })
+		order = order[:maxChartSeries]
+		sort.Ints(order)
+
+		top := make([]chartLine, 0, maxChartSeries)
+		for _, i := range order {
+			top = append(top, lines[i])
+		}
+		lines = top
+	}
+
+	format := c.FormatValue
+	if unit == "%" {
+		format = func(v float64) string { return fmt.Sprintf("%.1f%%", v) }
+	}
+	header := fmt.Sprintf("📈 %s за %s\n", title, periodName)
+	footer := ""
+	if hidden > 0 {
+		footer = fmt.Sprintf("Не показано рядов с меньшим средним: %d\n", hidden)
+	}
+	stats := make([]string, 0, len(lines))
+	for _, line := range lines {
+		c.Series = append(c.Series, line.series)
+		stats = append(stats, fmt.Sprintf("%s: мин %s · сред %s · макс %s",
+			line.series.Name, format(line.minValue), format(line.avg), format(line.maxValue)))
+	}
+	// Telegram считает длину подписи в кодовых единицах UTF-16, эмодзи заголовка занимает две
+	limit := maxCaptionLength - len(utf16.Encode([]rune(header+footer)))
+	caption := header + truncateLines(stats, limit) + footer
+
+	image, err := chart.Render(c)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Ошибка построения графика: %v", err))
+		h.bot.Send(msg)
+		return
+	}
+
+	photo := tgbotapi.NewPhotoUpload(chatID, tgbotapi.FileBytes{Name: "chart.png", Bytes: image})
+	photo.Caption = caption
+	if _, err := h.bot.Send(photo); err != nil {
+		log.Printf("Handler: Ошибка отправки графика: %v", err)
+	}
+}
+
+// chartSeriesByPrefix возвращает ряды истории с префиксом; имя ряда на графике - часть после префикса
+func (h *CommandHandler) chartSeriesByPrefix(prefix, suffix string) []chartSeries {
+	names, err := h.metricsStore.Series(prefix)
+	if err != nil {
+		return nil
+	}
+
+	sources := make([]chartSeries, 0, len(names))
+	for _, name := range names {
+		sources = append(sources, chartSeries{name: strings.TrimPrefix(name, prefix) + suffix, series: name})
+	}
+	return sources
+}
+
+// currentInterfaceSeries оставляет ряды интерфейсов, которые сейчас есть в системе: физических, а при их отсутствии всех
+// История хранит ряды давно удаленных veth контейнеров, которые не нужны на графике
+func (h *CommandHandler) currentInterfaceSeries(sources []chartSeries) []chartSeries {
+	counters, err := h.systemService.GetNetCounters()
+	if err != nil {
+		return sources
+	}
+	physical := false
+	for _, counter := range counters {
+		physical = physical || counter.Physical
+	}
+	current := make(map[string]bool, len(counters))
+	for _, counter := range counters {
+		if !physical || counter.Physical {
+			current[counter.Name] = true
+		}
+	}
+
+	var result []chartSeries
+	for _, source := range sources {
+		name := strings.TrimPrefix(strings.TrimPrefix(source.series, metrics.SeriesNetRx), metrics.SeriesNetTx)
+		if current[name] {
+			result = append(result, source)
+		}
+	}
+	return result
+}
+
+// chartThresholds возвращает пороги правил алертов для отображения на графике
+// invert переводит пороги из процента свободного в процент занятого
+func (h *CommandHandler) chartThresholds(metric string, invert bool) []chart.Threshold {
+	var thresholds []chart.Threshold
+	seen := make(map[float64]bool)
+	for _, threshold := range h.monitoring.Thresholds(metric) {
+		value := threshold.Value
+		if invert {
+			value = 100 - value
+		}
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+
+		color := chart.WarningColor
+		if threshold.Severity == notify.SeverityCritical {
+			color = chart.CriticalColor
+		}
+		thresholds = append(thresholds, chart.Threshold{
+			Label: fmt.Sprintf("%s %.0f%%", threshold.Severity, value),
+			Value: value,
+			Color: color,
+		})
+	}
+	return thresholds
+}
+
+// formatRate форматирует скорость передачи данных
+func formatRate(bytesPerSecond float64) string {
+	switch {
+	case bytesPerSecond >= 1<<30:
+		return fmt.Sprintf("%.1f GB/s", bytesPerSecond/(1<<30))
+	case bytesPerSecond >= 1<<20:
+		return fmt.Sprintf("%.1f MB/s", bytesPerSecond/(1<<20))
+	case bytesPerSecond >= 1<<10:
+		return fmt.Sprintf("%.1f KB/s", bytesPerSecond/(1<<10))
+	default:
+		return fmt.Sprintf("%.0f B/s", bytesPerSecond)
+	}
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"fmt"
+
+	"tgbot/internal/services/system"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// cleanupItemsLimit количество удаляемых объектов, перечисляемых перед подтверждением очистки
+const cleanupItemsLimit = 10
+
+// cleanupMenuText текст меню очистки
+const cleanupMenuText = "🧹 Очистка диска\n\nВыберите, что очистить. Перед очисткой будет показано, сколько места можно освободить."
+
+// cleanupActionOrder порядок действий в меню очистки
+var cleanupActionOrder = []string{"journal", "pkgcache", "logs", "kernels", "docker"}
+
+// cleanupActions действия очистки диска: подпись кнопки и заголовок отчета
+var cleanupActions = map[string]struct {
+	button string
+	title  string
+}{
+	"journal":  {"📜 Журнал systemd", "Сокращение журнала systemd"},
+	"pkgcache": {"📦 Кэш пакетов", "Очистка кэша пакетов"},
+	"logs":     {"🗒 Старые журналы", "Удаление ротированных журналов"},
+	"kernels":  {"🐧 Старые ядра", "Удаление старых ядер"},
+	"docker":   {"🐳 Docker", "Очистка Docker"},
+}
+
+// handleCleanup обрабатывает команду /cleanup
+func (h *CommandHandler) handleCleanup(chatID int64) {
+	msg := tgbotapi.NewMessage(chatID, cleanupMenuText)
+	msg.ReplyMarkup = cleanupKeyboard()
+	h.bot.Send(msg)
+}
+
+// handleCleanupMenu возвращает сообщение к меню очистки
+func (h *CommandHandler) handleCleanupMenu(callback *tgbotapi.CallbackQuery) {
+	keyboard := cleanupKeyboard()
+	editMsg := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, cleanupMenuText)
+	editMsg.ReplyMarkup = &keyboard
+	h.bot.Send(editMsg)
+}
+
+// handleCleanupConfirm оценивает освобождаемое место и запрашивает подтверждение очистки
+func (h *CommandHandler) handleCleanupConfirm(callback *tgbotapi.CallbackQuery, action string) {
+	if _, ok := cleanupActions[action]; !ok {
+		return
+	}
+
+	chatID, messageID := callback.Message.Chat.ID, callback.Message.MessageID
+	h.bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, "⏳ Оценка освобождаемого места..."))
+	// Оценка запускает пакетный менеджер или обходит /var/log, поэтому выполняется в фоне
+	go h.showCleanupEstimate(chatID, messageID, action)
+}
+
+// showCleanupEstimate выводит оценку освобождаемого места и кнопку подтверждения
+func (h *CommandHandler) showCleanupEstimate(chatID int64, messageID int, action string) {
+	backRow := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", "cleanup"),
+	)
+
+	estimate, err := h.estimateCleanup(action)
+	if err != nil {
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(backRow)
+		editMsg := tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("❌ %v", err))
+		editMsg.ReplyMarkup = &keyboard
+		h.bot.Send(editMsg)
+		return
+	}
+
+	message := fmt.Sprintf("🧹 %s\n\n%s\n", cleanupActions[action].title, h.cleanupDescription(action))
+	if estimate.Reclaimable == 0 && len(estimate.Items) == 0 {
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(backRow)
+		editMsg := tgbotapi.NewEditMessageText(chatID, messageID, message+"\n✅ Очищать нечего")
+		editMsg.ReplyMarkup = &keyboard
+		h.bot.Send(editMsg)
+		return
+	}
+
+	message += fmt.Sprintf("\nМожно освободить: ~%s\n", formatBytes(estimate.Reclaimable))
+	if len(estimate.Items) > 0 {
+		message += "\nБудет удалено:\n"
+		for i, item := range estimate.Items {
+			if i == cleanupItemsLimit {
+				message += fmt.Sprintf("… и еще %d\n", len(estimate.Items)-cleanupItemsLimit)
+				break
+			}
+			message += fmt.Sprintf("• %s\n", item)
+		}
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Очистить", "cleanup_do:"+action),
+		),
+		backRow,
+	)
+	editMsg := tgbotapi.NewEditMessageText(chatID, messageID, message)
+	editMsg.ReplyMarkup = &keyboard
+	h.bot.Send(editMsg)
+}
+
+// handleCleanupAction выполняет подтвержденную очистку
+func (h *CommandHandler) handleCleanupAction(callback *tgbotapi.CallbackQuery, action string) {
+	if _, ok := cleanupActions[action]; !ok {
+		return
+	}
+
+	chatID, messageID := callback.Message.Chat.ID, callback.Message.MessageID
+
+	// Одновременные очистки мешали бы друг другу (блокировка пакетного менеджера) и искажали бы подсчет места
+	h.mu.Lock()
+	if h.cleanupRunning {
+		h.mu.Unlock()
+		h.bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, "⏳ Уже выполняется другая очистка, повторите позже"))
+		return
+	}
+	h.cleanupRunning = true
+	h.mu.Unlock()
+
+	h.bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("⏳ %s...", cleanupActions[action].title)))
+	go h.runCleanup(chatID, messageID, action)
+}
+
+// runCleanup выполняет очистку и сообщает, сколько места освобождено
+func (h *CommandHandler) runCleanup(chatID int64, messageID int, action string) {
+	defer func() {
+		h.mu.Lock()
+		h.cleanupRunning = false
+		h.mu.Unlock()
+	}()
+
+	// Освобожденное место определяется по изменению свободного места на затронутых файловых системах
+	paths := h.cleanupPaths(action)
+	before := h.systemService.FreeSpace(paths...)
+
+	var note string
+	var err error
+	switch action {
+	case "journal":
+		err = h.systemService.VacuumJournal(h.config.Cleanup.JournalSize)
+	case "pkgcache":
+		err = h.systemService.CleanPackageCache()
+	case "logs":
+		err = h.systemService.RemoveRotatedLogs(h.config.Cleanup.LogAge)
+	case "kernels":
+		err = h.systemService.RemoveOldKernels()
+	case "docker":
+		var reclaimed string
+		reclaimed, err = h.dockerService.Prune()
+		if reclaimed != "" {
+			note = fmt.Sprintf("\nПо данным Docker: %s", reclaimed)
+		}
+	}
+
+	var freed uint64
+	if after := h.systemService.FreeSpace(paths...); after > before {
+		freed = after - before
+	}
+
+	var message string
+	if err != nil {
+		message = fmt.Sprintf("❌ %v\n\nОсвобождено до ошибки: %s", err, formatBytes(freed))
+	} else {
+		message = fmt.Sprintf("✅ %s завершено\n\nОсвобождено: %s%s", cleanupActions[action].title, formatBytes(freed), note)
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🧹 К очистке", "cleanup"),
+		),
+	)
+	editMsg := tgbotapi.NewEditMessageText(chatID, messageID, message)
+	editMsg.ReplyMarkup = &keyboard
+	h.bot.Send(editMsg)
+}
+
+// estimateCleanup оценивает место, освобождаемое действием очистки
+func (h *CommandHandler) estimateCleanup(action string) (*system.CleanupEstimate, error) {
+	switch action {
+	case "journal":
+		return h.systemService.EstimateJournalVacuum(h.config.Cleanup.JournalSize)
+	case "pkgcache":
+		return h.systemService.EstimatePackageCache()
+	case "logs":
+		return h.systemService.EstimateRotatedLogs(h.config.Cleanup.LogAge)
+	case "kernels":
+		return h.systemService.EstimateOldKernels()
+	case "docker":
+		reclaimable, err := h.dockerService.PruneEstimate()
+		if err != nil {
+			return nil, err
+		}
+		return &system.CleanupEstimate{Reclaimable: reclaimable}, nil
+	}
+	return nil, fmt.Errorf("неизвестное действие очистки %s", action)
+}
+
+// cleanupDescription возвращает описание действия очистки для подтверждения
+func (h *CommandHandler) cleanupDescription(action string) string {
+	switch action {
+	case "journal":
+		return fmt.Sprintf("Архивные файлы журнала будут удалены, пока его размер не станет меньше %d MB.", h.config.Cleanup.JournalSize)
+	case "pkgcache":
+		return "Загруженные файлы пакетов будут удалены из кэша, установленные пакеты не затрагиваются."
+	case "logs":
+		return fmt.Sprintf("Сжатые и пронумерованные копии журналов в /var/log старше %d дней будут удалены.", h.config.Cleanup.LogAge)
+	case "kernels":
+		return "Ядра, которые больше не нужны пакетному менеджеру, будут удалены. Работающее и последнее установленное ядро сохраняются."
+	case "docker":
+		return "Будут удалены остановленные контейнеры, неиспользуемые сети, образы без контейнеров и кэш сборки. Тома не затрагиваются."
+	}
+	return ""
+}
+
+// cleanupPaths возвращает пути, на файловых системах которых освобождается место
+func (h *CommandHandler) cleanupPaths(action string) []string {
+	switch action {
+	case "journal":
+		return []string{"/var/log/journal", "/run/log/journal"}
+	case "pkgcache":
+		if cacheDir, err := h.systemService.PackageCacheDir(); err == nil {
+			return []string{cacheDir}
+		}
+	case "logs":
+		return []string{duVarLog}
+	case "kernels":
+		return []string{"/boot", "/usr/lib/modules", "/usr/src"}
+	case "docker":
+		if rootDir, err := h.dockerService.GetRootDir(); err == nil && rootDir != "" {
+			return []string{rootDir}
+		}
+	}
+	return nil
+}
+
+// cleanupKeyboard создает кнопки меню очистки
+func cleanupKeyboard() tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, action := range cleanupActionOrder {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(cleanupActions[action].button, "cleanup_ask:"+action))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("📂 Что занимает место", "du_shortcut:menu"),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
@@ -4,26 +4,56 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"sync"
 
 	"tgbot/internal/services/docker"
+	"tgbot/internal/services/maintenance"
+	"tgbot/internal/services/metrics"
+	"tgbot/internal/services/monitoring"
 	"tgbot/internal/services/system"
+	"tgbot/internal/services/traffic"
+	"tgbot/pkg/config"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
 )
 
 // CommandHandler обработчик команд
 type CommandHandler struct {
-	bot           *tgbotapi.BotAPI
-	systemService *system.Monitor
-	dockerService *docker.Manager
+	bot            *tgbotapi.BotAPI
+	config         *config.Config
+	systemService  *system.Monitor
+	dockerService  *docker.Manager
+	scheduler      *maintenance.Scheduler
+	monitoring     *monitoring.Service
+	metricsStore   *metrics.Store
+	traffic        *traffic.Accountant
+	updateSessions map[int64]*updateSession
+	// upgradePreviews последний предпросмотр обновления в чате; подтверждается именно он
+	upgradePreviews map[int64]*upgradePreview
+	// duPaths пути каталогов по идентификаторам из кнопок /du
+	duPaths *buttonRefs
+	// cleanupRunning означает, что выполняется очистка диска
+	cleanupRunning bool
+	// fail2banTargets адреса в jail по идентификаторам из кнопок /fail2ban
+	fail2banTargets *buttonRefs
+	mu              sync.Mutex
 }
 
 // NewCommandHandler создает новый обработчик команд
-func NewCommandHandler(bot *tgbotapi.BotAPI, systemService *system.Monitor, dockerService *docker.Manager) *CommandHandler {
+func NewCommandHandler(bot *tgbotapi.BotAPI, cfg *config.Config, systemService *system.Monitor, dockerService *docker.Manager, scheduler *maintenance.Scheduler, monitoringService *monitoring.Service, metricsStore *metrics.Store, trafficAccountant *traffic.Accountant) *CommandHandler {
 	return &CommandHandler{
-		bot:           bot,
-		systemService: systemService,
-		dockerService: dockerService,
+		bot:             bot,
+		config:          cfg,
+		systemService:   systemService,
+		dockerService:   dockerService,
+		scheduler:       scheduler,
+		monitoring:      monitoringService,
+		metricsStore:    metricsStore,
+		traffic:         trafficAccountant,
+		updateSessions:  make(map[int64]*updateSession),
+		upgradePreviews: make(map[int64]*upgradePreview),
+		duPaths:         newButtonRefs(),
+		fail2banTargets: newButtonRefs(),
 	}
 }
 
@@ -45,12 +75,34 @@ func (h *CommandHandler) HandleCommand(update tgbotapi.Update) {
 			h.handleRAM(update)
 		case command == "/hdd":
 			h.handleHDD(update)
+		case command == "/net":
+			h.handleNet(update)
+		case command == "/du" || strings.HasPrefix(command, "/du "):
+			h.handleDU(update.Message.Chat.ID, strings.TrimSpace(strings.TrimPrefix(command, "/du")))
+		case command == "/cleanup":
+			h.handleCleanup(update.Message.Chat.ID)
+		case command == "/top" || strings.HasPrefix(command, "/top "):
+			h.handleTop(update.Message.Chat.ID, strings.Fields(strings.TrimPrefix(command, "/top")))
+		case command == "/who":
+			h.handleWho(update.Message.Chat.ID)
+		case command == "/fail2ban":
+			h.handleFail2ban(update.Message.Chat.ID)
+		case command == "/ban" || strings.HasPrefix(command, "/ban "):
+			h.handleFail2banCommand(update.Message.Chat.ID, "ban", strings.Fields(strings.TrimPrefix(command, "/ban")))
+		case command == "/unban" || strings.HasPrefix(command, "/unban "):
+			h.handleFail2banCommand(update.Message.Chat.ID, "unban", strings.Fields(strings.TrimPrefix(command, "/unban")))
 		case command == "/containers":
 			h.handleContainers(update)
 		case command == "/reboot":
 			h.handleReboot(update)
+		case strings.HasPrefix(command, "/reboot "):
+			h.handleRebootAtCommand(update, strings.TrimSpace(strings.TrimPrefix(command, "/reboot ")))
 		case command == "/shutdown":
 			h.handleShutdown(update)
+		case command == "/silences":
+			h.handleSilences(update.Message.Chat.ID)
+		case command == "/chart" || strings.HasPrefix(command, "/chart "):
+			h.handleChart(update.Message.Chat.ID, strings.Fields(strings.TrimPrefix(command, "/chart")))
 		default:
 			h.handleUnknown(update)
 		}
@@ -103,6 +155,12 @@ func (h *CommandHandler) handleStatus(update tgbotapi.Update) {
 
 `, cpuInfo, memInfo, diskInfo)
 
+	message += h.formatTrafficSummary()
+
+	message += h.formatSensorsSummary()
+
+	message += h.formatRebootStatus()
+
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, message)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = h.createBackKeyboard()
@@ -113,12 +171,13 @@ func (h *CommandHandler) handleStatus(update tgbotapi.Update) {
 // handleCPU обрабатывает команду /cpu
 func (h *CommandHandler) handleCPU(update tgbotapi.Update) {
 	// Получение информации о CPU
-	cpuInfo, _ := h.systemService.GetCPUInfoString()
-
-	message := fmt.Sprintf(`💻 *Статус CPU*
-
-%s
-`, cpuInfo)
+	message := "💻 *Статус CPU*\n\n"
+	cpuInfo, err := h.systemService.GetCPUInfo()
+	if err != nil {
+		message += fmt.Sprintf("❌ Информация о CPU недоступна: %v", err)
+	} else {
+		message += formatCPUDetails(cpuInfo)
+	}
 
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, message)
 	msg.ParseMode = "Markdown"
@@ -154,6 +213,8 @@ func (h *CommandHandler) handleHDD(update tgbotapi.Update) {
 %s
 `, diskInfo)
 
+	message += h.formatDiskForecast()
+
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, message)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = h.createBackKeyboard()
@@ -336,6 +397,24 @@ func (h *CommandHandler) handleCallbackQuery(update tgbotapi.Update) {
 		msg.ReplyMarkup = keyboard
 
 		h.bot.Send(msg)
+	} else if strings.HasPrefix(data, "pkg_toggle:") {
+		// Переключение выбора пакета для обновления
+		h.handlePackageToggle(callback, strings.TrimPrefix(data, "pkg_toggle:"))
+	} else if strings.HasPrefix(data, "upgrade_preview:") {
+		// Предпросмотр обновления
+		h.handleUpgradePreview(callback, strings.TrimPrefix(data, "upgrade_preview:"))
+	} else if strings.HasPrefix(data, "upgrade_confirm:") {
+		// Подтвержденное обновление
+		h.handleUpgradeConfirm(callback, strings.TrimPrefix(data, "upgrade_confirm:"))
+	} else if strings.HasPrefix(data, "reboot_at:") {
+		// Плановая перезагрузка в указанное время суток
+		h.handleRebootAt(callback.Message.Chat.ID, strings.TrimPrefix(data, "reboot_at:"))
+	} else if strings.HasPrefix(data, "reboot_in:") {
+		// Плановая перезагрузка через указанное количество минут
+		h.handleRebootIn(callback.Message.Chat.ID, strings.TrimPrefix(data, "reboot_in:"))
+	} else if strings.HasPrefix(data, "upgrade_log:") {
+		// Отправка журнала обновления
+		h.handleUpgradeLog(callback, strings.TrimPrefix(data, "upgrade_log:"))
 	} else if strings.HasPrefix(data, "restart_service:") {
 		// Перезапуск сервиса
 		serviceName := strings.TrimPrefix(data, "restart_service:")
@@ -348,6 +427,51 @@ func (h *CommandHandler) handleCallbackQuery(update tgbotapi.Update) {
 		// Запуск сервиса
 		serviceName := strings.TrimPrefix(data, "start_service:")
 		h.handleServiceAction(callback, "start", serviceName)
+	} else if strings.HasPrefix(data, "alert_ack:") {
+		// Подтверждение алерта
+		h.handleAlertAck(callback, strings.TrimPrefix(data, "alert_ack:"))
+	} else if strings.HasPrefix(data, "alert_silence:") {
+		// Заглушка алерта на время
+		h.handleAlertSilence(callback, strings.TrimPrefix(data, "alert_silence:"))
+	} else if strings.HasPrefix(data, "alert_unsilence:") {
+		// Снятие заглушки алерта
+		h.handleAlertUnsilence(callback, strings.TrimPrefix(data, "alert_unsilence:"))
+	} else if strings.HasPrefix(data, "du:") {
+		// Переход в каталог отчета /du
+		h.handleDUCallback(callback, strings.TrimPrefix(data, "du:"))
+	} else if strings.HasPrefix(data, "du_shortcut:") {
+		// Частые причины нехватки места
+		h.handleDUShortcut(callback, strings.TrimPrefix(data, "du_shortcut:"))
+	} else if strings.HasPrefix(data, "cleanup_ask:") {
+		// Оценка освобождаемого места и подтверждение очистки
+		h.handleCleanupConfirm(callback, strings.TrimPrefix(data, "cleanup_ask:"))
+	} else if strings.HasPrefix(data, "cleanup_do:") {
+		// Выполнение подтвержденной очистки
+		h.handleCleanupAction(callback, strings.TrimPrefix(data, "cleanup_do:"))
+	} else if strings.HasPrefix(data, "proc:") {
+		// Карточка процесса с действиями
+		h.handleProcess(callback, strings.TrimPrefix(data, "proc:"))
+	} else if strings.HasPrefix(data, "proc_ask:") {
+		// Подтверждение действия над процессом
+		h.handleProcessConfirm(callback, strings.TrimPrefix(data, "proc_ask:"))
+	} else if strings.HasPrefix(data, "proc_do:") {
+		// Выполнение подтвержденного действия над процессом
+		h.handleProcessAction(callback, strings.TrimPrefix(data, "proc_do:"))
+	} else if strings.HasPrefix(data, "session_ask:") {
+		// Подтверждение завершения сеанса
+		h.handleSessionConfirm(callback, strings.TrimPrefix(data, "session_ask:"))
+	} else if strings.HasPrefix(data, "session_do:") {
+		// Завершение подтвержденного сеанса
+		h.handleSessionAction(callback, strings.TrimPrefix(data, "session_do:"))
+	} else if strings.HasPrefix(data, "f2b_jail:") {
+		// Заблокированные адреса jail fail2ban
+		h.handleFail2banJail(callback, strings.TrimPrefix(data, "f2b_jail:"))
+	} else if strings.HasPrefix(data, "f2b_ask:") {
+		// Подтверждение блокировки или разблокировки адреса
+		h.handleFail2banConfirm(callback, strings.TrimPrefix(data, "f2b_ask:"))
+	} else if strings.HasPrefix(data, "f2b_do:") {
+		// Выполнение подтвержденной блокировки или разблокировки
+		h.handleFail2banAction(callback, strings.TrimPrefix(data, "f2b_do:"))
 	} else if strings.HasPrefix(data, "status_service:") {
 		// Получение статуса сервиса
 		serviceName := strings.TrimPrefix(data, "status_service:")
@@ -371,6 +495,21 @@ func (h *CommandHandler) handleCallbackQuery(update tgbotapi.Update) {
 				},
 			}
 			h.handleContainers(fakeUpdate)
+		case "top":
+			// Обновление списка процессов
+			h.handleTop(callback.Message.Chat.ID, nil)
+		case "who":
+			// Список сеансов новым сообщением
+			h.handleWho(callback.Message.Chat.ID)
+		case "session_list":
+			// Обновление списка сеансов
+			h.handleSessionList(callback)
+		case "f2b":
+			// Список jail fail2ban
+			h.handleFail2banMenu(callback)
+		case "cleanup":
+			// Меню очистки диска
+			h.handleCleanupMenu(callback)
 		case "services":
 			// Показываем список сервисов
 			h.handleServices(callback)
@@ -403,7 +542,7 @@ func (h *CommandHandler) handleCallbackQuery(update tgbotapi.Update) {
 			h.handleStart(fakeUpdate)
 		case "confirm_reboot":
 			// Выполняем перезагрузку сервера
-			err := h.systemService.Reboot()
+			err := h.scheduler.RebootNow(callback.Message.Chat.ID)
 			if err != nil {
 				message := fmt.Sprintf("❌ Ошибка перезагрузки сервера: %v", err)
 				msg := tgbotapi.NewMessage(callback.Message.Chat.ID, message)
@@ -427,70 +566,19 @@ func (h *CommandHandler) handleCallbackQuery(update tgbotapi.Update) {
 			}
 		case "check_updates":
 			// Проверка обновлений системы
-			message := "🔍 Проверяю доступные обновления..."
-			msg := tgbotapi.NewMessage(callback.Message.Chat.ID, message)
-			h.bot.Send(msg)
-
-			// Выполняем проверку обновлений
-			updates, err := h.systemService.CheckUpdates()
-			if err != nil {
-				message = fmt.Sprintf("❌ Ошибка проверки обновлений: %v", err)
-				msg = tgbotapi.NewMessage(callback.Message.Chat.ID, message)
-				h.bot.Send(msg)
-			} else {
-				// Проверяем, есть ли реальные пакеты для обновления
-				lines := strings.Split(updates, "\n")
-				packageCount := 0
-
-				// Подсчитываем количество строк с реальными пакетами (исключая заголовок и пустые строки)
-				for _, line := range lines {
-					if strings.Contains(line, "/") && !strings.HasPrefix(line, "Listing...") {
-						packageCount++
-					}
-				}
-
-				if packageCount == 0 {
-					// Если обновлений нет, выводим сообщение и возвращаем к основному меню
-					message = "✅ Все обновления установлены!"
-					msg = tgbotapi.NewMessage(callback.Message.Chat.ID, message)
-					h.bot.Send(msg)
-
-					// Создаем фиктивный update для возврата к основному меню
-					fakeUpdate := tgbotapi.Update{
-						Message: &tgbotapi.Message{
-							Chat: callback.Message.Chat,
-							Text: "/start",
-						},
-					}
-					h.handleStart(fakeUpdate)
-				} else {
-					// Если есть обновления, показываем их
-					// Ограничиваем вывод до 2000 символов
-					if len(updates) > 2000 {
-						updates = updates[:2000] + "\n... (вывод обрезан)"
-					}
-					message = fmt.Sprintf("🔍 *Доступные обновления:*\n```\n%s\n```", updates)
-					msg = tgbotapi.NewMessage(callback.Message.Chat.ID, message)
-					msg.ParseMode = "Markdown"
-					h.bot.Send(msg)
-				}
-			}
+			h.handleCheckUpdates(callback)
 		case "upgrade_system":
-			// Обновление системы
-			message := "⬆️ Начинаю обновление системы..."
-			msg := tgbotapi.NewMessage(callback.Message.Chat.ID, message)
-			h.bot.Send(msg)
-
-			// Выполняем обновление системы
-			err := h.systemService.UpgradeSystem()
-			if err != nil {
-				message = fmt.Sprintf("❌ Ошибка обновления системы: %v", err)
-			} else {
-				message = "✅ Система успешно обновлена!"
-			}
-
-			msg = tgbotapi.NewMessage(callback.Message.Chat.ID, message)
-			h.bot.Send(msg)
+			// Обновление всей системы после предпросмотра
+			h.handleUpgradePreview(callback, upgradeModeAll)
+		case "reboot_schedule":
+			// Меню плановой перезагрузки
+			h.handleRebootSchedule(callback)
+		case "reboot_cancel":
+			// Отмена плановой перезагрузки
+			h.handleRebootCancel(callback)
+		case "upgrade_select":
+			// Выбор пакетов для обновления
+			h.handleUpgradeSelect(callback)
 		}
 	}
 }
@@ -502,6 +590,9 @@ func (h *CommandHandler) handleServerManagement(callback *tgbotapi.CallbackQuery
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("🔄 Перезагрузить сервер", "reboot"),
 		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🕒 Запланировать перезагрузку", "reboot_schedule"),
+		),
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("🔌 Выключить сервер", "shutdown"),
 		),
@@ -548,8 +639,8 @@ func (h *CommandHandler) handleServices(callback *tgbotapi.CallbackQuery) {
 	for i := 0; i < limit; i++ {
 		service := services[i]
 		button := tgbotapi.NewInlineKeyboardButtonData(
-			service.Status,                          // Используем статус с цветовым индикатором
-			fmt.Sprintf("service:%s", service.Name), // Используем имя для callback
+			"🟩 "+service,                       // GetServices возвращает только активные сервисы
+			fmt.Sprintf("service:%s", service), // Используем имя для callback
 		)
 		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(button))
 	}
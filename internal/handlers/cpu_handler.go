@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"tgbot/internal/services/system"
+	"tgbot/pkg/schedule"
+)
+
+// coresPerLine количество ядер в одной строке раздела загрузки по ядрам
+const coresPerLine = 4
+
+// formatCPUDetails формирует подробный отчет /cpu
+func formatCPUDetails(cpuInfo *system.CPUInfo) string {
+	result := fmt.Sprintf("Модель: %s\n", cpuInfo.Model)
+	if cpuInfo.PhysicalCores > 0 && cpuInfo.PhysicalCores != cpuInfo.Cores {
+		result += fmt.Sprintf("Ядер: %d (физических: %d)\n", cpuInfo.Cores, cpuInfo.PhysicalCores)
+	} else {
+		result += fmt.Sprintf("Ядер: %d\n", cpuInfo.Cores)
+	}
+	result += fmt.Sprintf("Частота: %.2f MHz\n\n", cpuInfo.Frequency)
+
+	result += fmt.Sprintf("Загрузка: %.1f%%\n", cpuInfo.Load)
+	result += fmt.Sprintf("user %.1f%% · system %.1f%% · iowait %.1f%% · steal %.1f%%\n",
+		cpuInfo.User, cpuInfo.System, cpuInfo.IOWait, cpuInfo.Steal)
+	if cpuInfo.Steal >= 5 {
+		result += "⚠️ Гипервизор отбирает заметную часть времени CPU\n"
+	}
+	result += fmt.Sprintf("Переключений контекста: %.0f/с\n", cpuInfo.ContextSwitches)
+
+	if len(cpuInfo.PerCore) > 1 {
+		result += "\n*По ядрам*\n"
+		var line []string
+		for i, usage := range cpuInfo.PerCore {
+			line = append(line, fmt.Sprintf("%d: %.0f%%", i, usage))
+			if len(line) == coresPerLine || i == len(cpuInfo.PerCore)-1 {
+				result += strings.Join(line, " · ") + "\n"
+				line = nil
+			}
+		}
+	}
+
+	if cpuInfo.LoadAverage != nil && cpuInfo.Cores > 0 {
+		result += "\n*Load average* (на ядро)\n"
+		for _, item := range []struct {
+			name  string
+			value float64
+		}{
+			{"1 мин", cpuInfo.LoadAverage.Load1},
+			{"5 мин", cpuInfo.LoadAverage.Load5},
+			{"15 мин", cpuInfo.LoadAverage.Load15},
+		} {
+			perCore := item.value / float64(cpuInfo.Cores)
+			icon := "🟢"
+			switch {
+			case perCore >= 1:
+				icon = "🔴"
+			case perCore >= 0.7:
+				icon = "🟡"
+			}
+			result += fmt.Sprintf("%s %s: %.2f (%.2f)\n", icon, item.name, item.value, perCore)
+		}
+	}
+
+	if !cpuInfo.BootTime.IsZero() {
+		result += fmt.Sprintf("\n🖥 Время работы: %s (загрузка %s)\n",
+			schedule.FormatDuration(cpuInfo.Uptime), cpuInfo.BootTime.Format("2006-01-02 15:04"))
+	}
+
+	return result
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"tgbot/internal/services/metrics"
+	"tgbot/pkg/schedule"
+)
+
+// formatDiskForecast формирует раздел /hdd с прогнозом заполнения дисков по истории метрик
+func (h *CommandHandler) formatDiskForecast() string {
+	if h.metricsStore == nil {
+		return ""
+	}
+	diskInfos, err := h.systemService.GetDiskInfo()
+	if err != nil {
+		return ""
+	}
+
+	now := time.Now()
+	result := ""
+	for _, diskInfo := range diskInfos {
+		forecast, err := h.metricsStore.ForecastFull(metrics.SeriesDisk+diskInfo.MountPoint, metrics.ForecastWindow, now)
+		if err != nil || forecast == nil {
+			continue
+		}
+
+		if forecast.Growing() {
+			result += fmt.Sprintf("%s: заполнится через ~%s при текущем темпе (%+.2f%% в сутки)\n",
+				diskInfo.MountPoint, schedule.FormatDuration(forecast.TimeToFull), forecast.Rate)
+		} else {
+			result += fmt.Sprintf("%s: не растет (%+.2f%% в сутки)\n", diskInfo.MountPoint, forecast.Rate)
+		}
+	}
+
+	if result == "" {
+		return ""
+	}
+	return "📈 *Прогноз заполнения*\n" + result
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// duLimit количество подкаталогов и файлов в отчете /du
+const duLimit = 10
+
+// duVarLog каталог журналов, часто занимающий место
+const duVarLog = "/var/log"
+
+// handleDU обрабатывает команду /du [путь]
+func (h *CommandHandler) handleDU(chatID int64, path string) {
+	if path == "" {
+		msg := tgbotapi.NewMessage(chatID, "📂 Что занимает место?\n\nИспользование: /du <путь> или выберите частую причину:")
+		msg.ReplyMarkup = duShortcutsKeyboard()
+		h.bot.Send(msg)
+		return
+	}
+
+	// Подсчет может занять до 20 секунд, поэтому выполняется в фоне, не задерживая другие команды
+	sent, err := h.bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("⏳ Подсчет места в %s...", path)))
+	if err != nil {
+		return
+	}
+	go h.showDirUsage(chatID, sent.MessageID, path)
+}
+
+// handleDUCallback обрабатывает переход в каталог из отчета /du
+func (h *CommandHandler) handleDUCallback(callback *tgbotapi.CallbackQuery, id string) {
+	path, ok := h.duPaths.Get(id)
+
+	chatID, messageID := callback.Message.Chat.ID, callback.Message.MessageID
+	if !ok {
+		h.bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, "⌛ Отчет устарел, повторите /du"))
+		return
+	}
+
+	h.bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("⏳ Подсчет места в %s...", path)))
+	go h.showDirUsage(chatID, messageID, path)
+}
+
+// handleDUShortcut обрабатывает кнопки частых причин нехватки места
+func (h *CommandHandler) handleDUShortcut(callback *tgbotapi.CallbackQuery, shortcut string) {
+	chatID, messageID := callback.Message.Chat.ID, callback.Message.MessageID
+
+	switch shortcut {
+	case "docker":
+		message := "🐳 Место, занятое Docker\n\n"
+		usage, err := h.dockerService.GetDiskUsage()
+		if err != nil {
+			message += fmt.Sprintf("❌ %v\n", err)
+		} else {
+			message += usage + "\n"
+		}
+
+		keyboard := duShortcutsKeyboard()
+		if rootDir, err := h.dockerService.GetRootDir(); err == nil && rootDir != "" {
+			keyboard.InlineKeyboard = append([][]tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardRow(
+					tgbotapi.NewInlineKeyboardButtonData("📁 "+rootDir, "du:"+h.duPathID(rootDir)),
+				),
+			}, keyboard.InlineKeyboard...)
+		}
+		editMsg := tgbotapi.NewEditMessageText(chatID, messageID, message)
+		editMsg.ReplyMarkup = &keyboard
+		h.bot.Send(editMsg)
+	case "journal":
+		message := "📜 Журнал systemd\n\n"
+		usage, err := h.systemService.JournalDiskUsage()
+		if err != nil {
+			message += fmt.Sprintf("❌ %v", err)
+		} else {
+			message += usage
+		}
+		keyboard := duShortcutsKeyboard()
+		editMsg := tgbotapi.NewEditMessageText(chatID, messageID, message)
+		editMsg.ReplyMarkup = &keyboard
+		h.bot.Send(editMsg)
+	case "pkgcache":
+		cacheDir, err := h.systemService.PackageCacheDir()
+		if err != nil {
+			h.bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("❌ %v", err)))
+			return
+		}
+		h.bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("⏳ Подсчет места в %s...", cacheDir)))
+		go h.showDirUsage(chatID, messageID, cacheDir)
+	case "varlog":
+		h.bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("⏳ Подсчет места в %s...", duVarLog)))
+		go h.showDirUsage(chatID, messageID, duVarLog)
+	case "menu":
+		keyboard := duShortcutsKeyboard()
+		editMsg := tgbotapi.NewEditMessageText(chatID, messageID, "📂 Что занимает место?\n\nИспользование: /du <путь> или выберите частую причину:")
+		editMsg.ReplyMarkup = &keyboard
+		h.bot.Send(editMsg)
+	}
+}
+
+// showDirUsage подсчитывает место в каталоге и выводит отчет в сообщение с кнопками перехода
+func (h *CommandHandler) showDirUsage(chatID int64, messageID int, path string) {
+	usage, err := h.systemService.GetDirUsage(path, duLimit)
+	if err != nil {
+		keyboard := duShortcutsKeyboard()
+		editMsg := tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("❌ %v", err))
+		editMsg.ReplyMarkup = &keyboard
+		h.bot.Send(editMsg)
+		return
+	}
+
+	message := fmt.Sprintf("📂 %s — %s\n", usage.Path, formatBytes(usage.Total))
+	if len(usage.Dirs) > 0 {
+		message += "\nКаталоги:\n"
+		for _, dir := range usage.Dirs {
+			message += fmt.Sprintf("• %s/ — %s\n", dir.Path, formatBytes(dir.Size))
+		}
+	}
+	if len(usage.Files) > 0 {
+		message += "\nКрупные файлы:\n"
+		for _, file := range usage.Files {
+			message += fmt.Sprintf("• %s — %s\n", file.Path, formatBytes(file.Size))
+		}
+	}
+	if usage.Truncated {
+		message += "\n⚠️ Подсчет прерван по времени, размеры занижены"
+	}
+	if usage.Denied > 0 {
+		message += fmt.Sprintf("\n🔒 Нет доступа к каталогам: %d, их содержимое не учтено", usage.Denied)
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, dir := range usage.Dirs {
+		child := filepath.Join(usage.Path, dir.Path)
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("📁 %s (%s)", dir.Path, formatBytes(dir.Size)), "du:"+h.duPathID(child)))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	navigation := []tgbotapi.InlineKeyboardButton{}
+	if parent := filepath.Dir(usage.Path); parent != usage.Path {
+		navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData("⬆️ "+parent, "du:"+h.duPathID(parent)))
+	}
+	navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData("🔄 Обновить", "du:"+h.duPathID(usage.Path)))
+	rows = append(rows, navigation)
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("📋 Частые причины", "du_shortcut:menu"),
+	))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	editMsg := tgbotapi.NewEditMessageText(chatID, messageID, strings.TrimSpace(message))
+	editMsg.ReplyMarkup = &keyboard
+	h.bot.Send(editMsg)
+}
+
+// duPathID возвращает короткий идентификатор пути для callback-данных и запоминает путь
+// Пути могут быть длиннее ограничения Telegram на callback-данные в 64 байта
+func (h *CommandHandler) duPathID(path string) string {
+	return h.duPaths.Add(path)
+}
+
+// duShortcutsKeyboard создает кнопки частых причин нехватки места
+func duShortcutsKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🐳 Docker", "du_shortcut:docker"),
+			tgbotapi.NewInlineKeyboardButtonData("📜 Журнал", "du_shortcut:journal"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📦 Кэш пакетов", "du_shortcut:pkgcache"),
+			tgbotapi.NewInlineKeyboardButtonData("🗒 "+duVarLog, "du_shortcut:varlog"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🧹 Очистка", "cleanup"),
+		),
+	)
+}
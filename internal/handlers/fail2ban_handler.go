@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"tgbot/internal/services/system"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// fail2banIPButtonsLimit количество заблокированных адресов, для которых выводятся кнопки разблокировки
+const fail2banIPButtonsLimit = 20
+
+// fail2banIPListLimit количество заблокированных адресов, перечисляемых в сообщении jail
+const fail2banIPListLimit = 50
+
+// fail2banTarget адрес в jail, над которым выполняется блокировка или разблокировка
+type fail2banTarget struct {
+	jail string
+	ip   string
+}
+
+// fail2banActions действия над адресом: текст подтверждения и результата
+var fail2banActions = map[string]struct {
+	confirm string
+	done    string
+}{
+	"ban":   {"Заблокировать", "заблокирован"},
+	"unban": {"Разблокировать", "разблокирован"},
+}
+
+// handleFail2ban обрабатывает команду /fail2ban: отправляет список jail новым сообщением
+func (h *CommandHandler) handleFail2ban(chatID int64) {
+	message, keyboard := h.fail2banOverview()
+	msg := tgbotapi.NewMessage(chatID, message)
+	if keyboard != nil {
+		msg.ReplyMarkup = *keyboard
+	}
+	h.bot.Send(msg)
+}
+
+// handleFail2banMenu обновляет список jail в текущем сообщении
+func (h *CommandHandler) handleFail2banMenu(callback *tgbotapi.CallbackQuery) {
+	message, keyboard := h.fail2banOverview()
+	h.editFail2banMessage(callback, message, keyboard)
+}
+
+// fail2banOverview формирует список jail со счетчиками неудачных попыток и блокировок
+func (h *CommandHandler) fail2banOverview() (string, *tgbotapi.InlineKeyboardMarkup) {
+	if !h.systemService.Fail2banAvailable() {
+		return "ℹ️ fail2ban не установлен", nil
+	}
+	jails, err := h.systemService.GetFail2banJails()
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err), nil
+	}
+
+	message := "🛡 fail2ban\n\n"
+	if len(jails) == 0 {
+		message += "Нет активных jail\n"
+	}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, jail := range jails {
+		message += fmt.Sprintf("%s %s\n  попытки: %d (всего %d) · блокировки: %d (всего %d)\n",
+			fail2banJailIcon(jail), jail.Name, jail.CurrentlyFailed, jail.TotalFailed, jail.CurrentlyBanned, jail.TotalBanned)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%s (%d)", jail.Name, jail.CurrentlyBanned), "f2b_jail:"+jail.Name),
+		))
+	}
+	message += "\nБлокировка вручную: /ban <jail> <IP>, разблокировка: /unban <jail> <IP>"
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔄 Обновить", "f2b"),
+	))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return message, &keyboard
+}
+
+// handleFail2banJail показывает jail и заблокированные адреса с кнопками разблокировки
+func (h *CommandHandler) handleFail2banJail(callback *tgbotapi.CallbackQuery, name string) {
+	backRow := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔄 Обновить", "f2b_jail:"+name),
+		tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", "f2b"),
+	)
+
+	jail, err := h.systemService.GetFail2banJail(name)
+	if err != nil {
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(backRow)
+		h.editFail2banMessage(callback, fmt.Sprintf("❌ %v", err), &keyboard)
+		return
+	}
+
+	message := fmt.Sprintf("%s Jail %s\n\nНеудачные попытки: %d (всего %d)\nЗаблокировано: %d (всего %d)\n",
+		fail2banJailIcon(jail), jail.Name, jail.CurrentlyFailed, jail.TotalFailed, jail.CurrentlyBanned, jail.TotalBanned)
+	if len(jail.BannedIPs) > 0 {
+		message += "\nЗаблокированные адреса:\n"
+		for i, ip := range jail.BannedIPs {
+			if i == fail2banIPListLimit {
+				message += fmt.Sprintf("… и еще %d\n", len(jail.BannedIPs)-fail2banIPListLimit)
+				break
+			}
+			message += ip + "\n"
+		}
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for i, ip := range jail.BannedIPs {
+		if i == fail2banIPButtonsLimit {
+			break
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("🔓 "+ip, "f2b_ask:unban:"+h.fail2banTargetID(jail.Name, ip)))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, backRow)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.editFail2banMessage(callback, message, &keyboard)
+}
+
+// handleFail2banCommand обрабатывает команды /ban и /unban: запрашивает подтверждение действия
+func (h *CommandHandler) handleFail2banCommand(chatID int64, action string, args []string) {
+	if len(args) != 2 || net.ParseIP(args[1]) == nil {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("ℹ️ Использование: /%s <jail> <IP>", action))
+		h.bot.Send(msg)
+		return
+	}
+	if !h.systemService.Fail2banAvailable() {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ fail2ban не установлен"))
+		return
+	}
+
+	message, keyboard := h.fail2banConfirmation(action, args[0], args[1], h.fail2banTargetID(args[0], args[1]))
+	msg := tgbotapi.NewMessage(chatID, message)
+	msg.ReplyMarkup = keyboard
+	h.bot.Send(msg)
+}
+
+// handleFail2banConfirm запрашивает подтверждение действия над адресом
+// data имеет вид "<действие>:<идентификатор адреса>"
+func (h *CommandHandler) handleFail2banConfirm(callback *tgbotapi.CallbackQuery, data string) {
+	parts := strings.SplitN(data, ":", 2)
+	if _, ok := fail2banActions[parts[0]]; !ok || len(parts) != 2 {
+		return
+	}
+	target, ok := h.fail2banTarget(parts[1])
+	if !ok {
+		h.editFail2banMessage(callback, "❌ Кнопка устарела, откройте /fail2ban заново", nil)
+		return
+	}
+
+	message, keyboard := h.fail2banConfirmation(parts[0], target.jail, target.ip, parts[1])
+	h.editFail2banMessage(callback, message, &keyboard)
+}
+
+// fail2banConfirmation формирует запрос подтверждения действия над адресом
+func (h *CommandHandler) fail2banConfirmation(action, jail, ip, id string) (string, tgbotapi.InlineKeyboardMarkup) {
+	message := fmt.Sprintf("⚠️ %s %s в jail %s?", fail2banActions[action].confirm, ip, jail)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Подтвердить", "f2b_do:"+action+":"+id),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "f2b_jail:"+jail),
+		),
+	)
+	return message, keyboard
+}
+
+// handleFail2banAction выполняет подтвержденное действие над адресом
+// data имеет вид "<действие>:<идентификатор адреса>"
+func (h *CommandHandler) handleFail2banAction(callback *tgbotapi.CallbackQuery, data string) {
+	parts := strings.SplitN(data, ":", 2)
+	action, ok := fail2banActions[parts[0]]
+	if !ok || len(parts) != 2 {
+		return
+	}
+	target, ok := h.fail2banTarget(parts[1])
+	if !ok {
+		h.editFail2banMessage(callback, "❌ Кнопка устарела, откройте /fail2ban заново", nil)
+		return
+	}
+
+	var err error
+	if parts[0] == "ban" {
+		err = h.systemService.Fail2banBan(target.jail, target.ip)
+	} else {
+		err = h.systemService.Fail2banUnban(target.jail, target.ip)
+	}
+	message := fmt.Sprintf("✅ Адрес %s %s в jail %s", target.ip, action.done, target.jail)
+	if err != nil {
+		message = fmt.Sprintf("❌ %v", err)
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🛡 "+target.jail, "f2b_jail:"+target.jail),
+		),
+	)
+	h.editFail2banMessage(callback, message, &keyboard)
+}
+
+// editFail2banMessage заменяет текст сообщения fail2ban
+func (h *CommandHandler) editFail2banMessage(callback *tgbotapi.CallbackQuery, message string, keyboard *tgbotapi.InlineKeyboardMarkup) {
+	editMsg := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, message)
+	editMsg.ReplyMarkup = keyboard
+	h.bot.Send(editMsg)
+}
+
+// fail2banTargetID возвращает короткий идентификатор адреса в jail для callback-данных и запоминает его
+// Имя jail вместе с адресом IPv6 может не уместиться в ограничение Telegram на callback-данные в 64 байта
+func (h *CommandHandler) fail2banTargetID(jail, ip string) string {
+	// Имя jail не содержит пробелов, поэтому пробел однозначно разделяет jail и адрес
+	return h.fail2banTargets.Add(jail + " " + ip)
+}
+
+// fail2banTarget возвращает адрес в jail по идентификатору из кнопки
+// Идентификаторы не повторяются, поэтому кнопка всегда указывает на адрес, показанный при её создании
+func (h *CommandHandler) fail2banTarget(id string) (fail2banTarget, bool) {
+	value, ok := h.fail2banTargets.Get(id)
+	if !ok {
+		return fail2banTarget{}, false
+	}
+	jail, ip, ok := strings.Cut(value, " ")
+	if !ok || net.ParseIP(ip) == nil {
+		return fail2banTarget{}, false
+	}
+	return fail2banTarget{jail: jail, ip: ip}, true
+}
+
+// fail2banJailIcon возвращает индикатор jail: есть ли заблокированные адреса
+func fail2banJailIcon(jail *system.Fail2banJail) string {
+	if jail.CurrentlyBanned > 0 {
+		return "🔒"
+	}
+	return "🟢"
+}
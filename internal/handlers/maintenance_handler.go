@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tgbot/pkg/schedule"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// handleRebootSchedule показывает меню плановой перезагрузки
+func (h *CommandHandler) handleRebootSchedule(callback *tgbotapi.CallbackQuery) {
+	buttons := make([][]tgbotapi.InlineKeyboardButton, 0)
+
+	message := "🕒 *Плановая перезагрузка*\n\n"
+	if scheduled := h.scheduler.Scheduled(); scheduled != nil {
+		message += fmt.Sprintf("Запланирована на %s (через %s)\n\n",
+			scheduled.At.Format("2006-01-02 15:04"), schedule.FormatDuration(time.Until(scheduled.At)))
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отменить перезагрузку", "reboot_cancel"),
+		))
+	}
+	message += "Выберите время перезагрузки:"
+
+	buttons = append(buttons,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("🌙 Ночью в %s", h.config.Maintenance.RebootTime),
+				"reboot_at:"+h.config.Maintenance.RebootTime,
+			),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⏱ Через 1 час", "reboot_in:60"),
+			tgbotapi.NewInlineKeyboardButtonData("⏱ Через 4 часа", "reboot_in:240"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", "server_management"),
+		),
+	)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	editMsg := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, message)
+	editMsg.ParseMode = "Markdown"
+	editMsg.ReplyMarkup = &keyboard
+
+	h.bot.Send(editMsg)
+}
+
+// handleRebootAtCommand обрабатывает команду /reboot ЧЧ:ММ
+func (h *CommandHandler) handleRebootAtCommand(update tgbotapi.Update, clock string) {
+	h.handleRebootAt(update.Message.Chat.ID, clock)
+}
+
+// handleRebootAt планирует перезагрузку на ближайшее указанное время суток
+func (h *CommandHandler) handleRebootAt(chatID int64, clockStr string) {
+	clock, err := schedule.ParseClock(clockStr)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ %v", err))
+		h.bot.Send(msg)
+		return
+	}
+
+	h.scheduleReboot(chatID, clock.Next(time.Now()))
+}
+
+// handleRebootIn планирует перезагрузку через указанное количество минут
+func (h *CommandHandler) handleRebootIn(chatID int64, minutesStr string) {
+	minutes, err := strconv.Atoi(minutesStr)
+	if err != nil || minutes <= 0 {
+		msg := tgbotapi.NewMessage(chatID, "❌ Некорректное время перезагрузки")
+		h.bot.Send(msg)
+		return
+	}
+
+	h.scheduleReboot(chatID, time.Now().Add(time.Duration(minutes)*time.Minute))
+}
+
+// scheduleReboot планирует перезагрузку и сообщает об этом в чат
+func (h *CommandHandler) scheduleReboot(chatID int64, at time.Time) {
+	var message string
+	if err := h.scheduler.Schedule(at, chatID); err != nil {
+		message = fmt.Sprintf("❌ Ошибка планирования перезагрузки: %v", err)
+	} else {
+		message = fmt.Sprintf("🕒 Перезагрузка запланирована на %s (через %s)\nПеред перезагрузкой придет напоминание.",
+			at.Format("2006-01-02 15:04"), schedule.FormatDuration(time.Until(at)))
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отменить перезагрузку", "reboot_cancel"),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, message)
+	msg.ReplyMarkup = keyboard
+	h.bot.Send(msg)
+}
+
+// handleRebootCancel отменяет плановую перезагрузку
+func (h *CommandHandler) handleRebootCancel(callback *tgbotapi.CallbackQuery) {
+	message := "ℹ️ Плановая перезагрузка не запланирована"
+	if h.scheduler.Cancel() {
+		message = "✅ Плановая перезагрузка отменена"
+	}
+
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID, message)
+	h.bot.Send(msg)
+}
+
+// formatRebootStatus формирует раздел статуса о необходимости и расписании перезагрузки
+func (h *CommandHandler) formatRebootStatus() string {
+	result := ""
+
+	status := h.systemService.GetRebootStatus()
+	if status.Required {
+		result += "🔄 Требуется перезагрузка"
+		if len(status.Packages) > 0 {
+			result += fmt.Sprintf(" (пакеты: %s)", strings.Join(status.Packages, ", "))
+		}
+		result += "\n"
+	}
+
+	if scheduled := h.scheduler.Scheduled(); scheduled != nil {
+		result += fmt.Sprintf("🕒 Перезагрузка запланирована на %s\n", scheduled.At.Format("2006-01-02 15:04"))
+	}
+
+	return result
+}
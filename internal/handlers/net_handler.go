@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"tgbot/internal/services/traffic"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// handleNet обрабатывает команду /net
+func (h *CommandHandler) handleNet(update tgbotapi.Update) {
+	message := "🌐 *Сеть*\n\n"
+
+	interfaces, err := h.systemService.GetNetInterfaces()
+	if err != nil {
+		message += fmt.Sprintf("❌ Ошибка получения сетевых интерфейсов: %v\n", err)
+	} else {
+		// Виртуальные интерфейсы контейнеров только перечисляются количеством
+		veth := 0
+		for _, iface := range interfaces {
+			if strings.HasPrefix(iface.Name, "veth") {
+				veth++
+				continue
+			}
+
+			icon := "🟢"
+			switch {
+			case !iface.Up || iface.State == "down":
+				icon = "🔴"
+			case iface.State != "up":
+				icon = "⚪"
+			}
+			message += fmt.Sprintf("%s `%s` (%s, MTU %d)\n", icon, iface.Name, iface.State, iface.MTU)
+			if len(iface.Addrs) > 0 {
+				message += fmt.Sprintf("  %s\n", strings.Join(iface.Addrs, ", "))
+			}
+			message += fmt.Sprintf("  ↓ %s · ↑ %s\n", formatRate(iface.RxRate), formatRate(iface.TxRate))
+			message += fmt.Sprintf("  С загрузки: ↓ %s · ↑ %s\n",
+				formatBytes(iface.Counters.BytesRecv), formatBytes(iface.Counters.BytesSent))
+
+			counters := iface.Counters
+			if counters.ErrorsIn+counters.ErrorsOut+counters.DropsIn+counters.DropsOut > 0 {
+				message += fmt.Sprintf("  ⚠️ Ошибки: вх %d, исх %d · Отброшено: вх %d, исх %d\n",
+					counters.ErrorsIn, counters.ErrorsOut, counters.DropsIn, counters.DropsOut)
+			}
+			message += "\n"
+		}
+		if veth > 0 {
+			message += fmt.Sprintf("🐳 Интерфейсов контейнеров (veth): %d\n\n", veth)
+		}
+	}
+
+	message += h.formatTrafficReport()
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, message)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = h.createBackKeyboard()
+
+	h.bot.Send(msg)
+}
+
+// formatTrafficReport формирует раздел /net с трафиком за расчетный период
+func (h *CommandHandler) formatTrafficReport() string {
+	now := time.Now()
+	report := h.traffic.Report(now)
+
+	result := fmt.Sprintf("📊 *Трафик за период* с %s по %s\n",
+		report.PeriodStart.Format("02.01"), report.PeriodEnd.AddDate(0, 0, -1).Format("02.01"))
+	if report.TrackedSince.After(report.PeriodStart) {
+		result += fmt.Sprintf("(учет ведется с %s)\n", report.TrackedSince.Format("02.01 15:04"))
+	}
+	for _, name := range report.Names() {
+		usage := report.Interfaces[name]
+		result += fmt.Sprintf("`%s`: ↓ %s · ↑ %s\n", name, formatBytes(usage.Rx), formatBytes(usage.Tx))
+	}
+	result += fmt.Sprintf("Всего: ↓ %s · ↑ %s\n", formatBytes(report.Total.Rx), formatBytes(report.Total.Tx))
+	result += formatTrafficQuota(report)
+
+	if report.Previous != nil {
+		result += fmt.Sprintf("Прошлый период: ↓ %s · ↑ %s\n", formatBytes(report.Previous.Rx), formatBytes(report.Previous.Tx))
+	}
+	return result
+}
+
+// formatTrafficSummary формирует краткий раздел /status с трафиком за период
+func (h *CommandHandler) formatTrafficSummary() string {
+	report := h.traffic.Report(time.Now())
+	return fmt.Sprintf("🌐 Трафик с %s: ↓ %s · ↑ %s\n%s\n",
+		report.PeriodStart.Format("02.01"), formatBytes(report.Total.Rx), formatBytes(report.Total.Tx), formatTrafficQuota(report))
+}
+
+// formatTrafficQuota формирует строку расхода квоты трафика и прогноза к концу периода
+func formatTrafficQuota(report *traffic.Report) string {
+	if report.Quota == 0 {
+		return ""
+	}
+
+	direction := ""
+	switch report.Direction {
+	case traffic.DirectionRx:
+		direction = ", входящий"
+	case traffic.DirectionTx:
+		direction = ", исходящий"
+	}
+	result := fmt.Sprintf("Квота%s: %s из %s (%.1f%%)\n",
+		direction, formatBytes(report.Used), formatBytes(report.Quota), report.UsedPercent())
+
+	if report.Projected > 0 {
+		icon := "✅"
+		if report.Projected > report.Quota {
+			icon = "⚠️"
+		}
+		result += fmt.Sprintf("%s Прогноз к концу периода: %s (%.0f%% квоты)\n",
+			icon, formatBytes(report.Projected), report.ProjectedPercent())
+	}
+	return result
+}
+
+// formatBytes форматирует объем данных
+func formatBytes(bytes uint64) string {
+	value := float64(bytes)
+	switch {
+	case value >= 1<<40:
+		return fmt.Sprintf("%.2f TB", value/(1<<40))
+	case value >= 1<<30:
+		return fmt.Sprintf("%.2f GB", value/(1<<30))
+	case value >= 1<<20:
+		return fmt.Sprintf("%.1f MB", value/(1<<20))
+	case value >= 1<<10:
+		return fmt.Sprintf("%.1f KB", value/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"tgbot/internal/services/system"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Количество процессов в /top по умолчанию и максимальное
+const (
+	defaultTopLimit = 10
+	maxTopLimit     = 20
+)
+
+// telegramMessageLimit максимальная длина текста сообщения Telegram в символах
+const telegramMessageLimit = 4096
+
+// topCommandLengths длины командной строки, до которых она сокращается, чтобы /top уместился в одно сообщение
+var topCommandLengths = []int{80, 40, 20, 0}
+
+// processActions действия над процессом: подпись кнопки и текст подтверждения
+var processActions = map[string]struct {
+	button  string
+	confirm string
+}{
+	"term":   {"🛑 SIGTERM", "Отправить SIGTERM (корректное завершение)"},
+	"kill":   {"💀 SIGKILL", "Отправить SIGKILL (принудительное завершение)"},
+	"nice10": {"🐢 nice 10", "Понизить приоритет до 10"},
+	"nice19": {"🐌 nice 19", "Понизить приоритет до 19"},
+	"nice0":  {"⚖️ nice 0", "Вернуть обычный приоритет 0"},
+}
+
+// handleTop показывает процессы с наибольшей загрузкой CPU и потреблением памяти
+func (h *CommandHandler) handleTop(chatID int64, args []string) {
+	limit := defaultTopLimit
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || n > maxTopLimit {
+			msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("ℹ️ Использование: /top [1-%d]", maxTopLimit))
+			h.bot.Send(msg)
+			return
+		}
+		limit = n
+	}
+
+	top, err := h.systemService.GetTopProcesses(limit)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Ошибка получения списка процессов: %v", err))
+		h.bot.Send(msg)
+		return
+	}
+
+	message := formatTop(top)
+
+	// Кнопки для каждого процесса из обоих списков без повторов
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	seen := make(map[int32]bool)
+	for _, proc := range append(top.ByCPU, top.ByMemory...) {
+		if seen[proc.PID] {
+			continue
+		}
+		seen[proc.PID] = true
+
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("🔎 %d %s", proc.PID, proc.Name), "proc:"+processRef(proc)))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔄 Обновить", "top"),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, message)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := h.bot.Send(msg); err != nil {
+		log.Printf("Handler: Ошибка отправки списка процессов: %v", err)
+	}
+}
+
+// handleProcess показывает процесс и доступные действия
+func (h *CommandHandler) handleProcess(callback *tgbotapi.CallbackQuery, ref string) {
+	pid, createTime, err := parseProcessRef(ref)
+	if err != nil {
+		h.editProcessMessage(callback, fmt.Sprintf("❌ %v", err), nil)
+		return
+	}
+
+	proc, err := h.systemService.GetProcess(pid, createTime)
+	if err != nil {
+		h.editProcessMessage(callback, fmt.Sprintf("❌ %v", err), nil)
+		return
+	}
+
+	message := fmt.Sprintf("⚙️ Процесс %d\n\nИмя: %s\nПользователь: %s\nКоманда: %s\nRSS: %.1f MB (%.1f%%)\nNice: %d\nЗапущен: %s",
+		proc.PID, proc.Name, proc.User, proc.Command, float64(proc.RSS)/(1<<20), proc.MemoryPercent,
+		proc.Nice, proc.StartTime().Format("2006-01-02 15:04:05"))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(processActions["term"].button, "proc_ask:term:"+ref),
+			tgbotapi.NewInlineKeyboardButtonData(processActions["kill"].button, "proc_ask:kill:"+ref),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(processActions["nice10"].button, "proc_ask:nice10:"+ref),
+			tgbotapi.NewInlineKeyboardButtonData(processActions["nice19"].button, "proc_ask:nice19:"+ref),
+			tgbotapi.NewInlineKeyboardButtonData(processActions["nice0"].button, "proc_ask:nice0:"+ref),
+		),
+	)
+	h.editProcessMessage(callback, message, &keyboard)
+}
+
+// handleProcessConfirm запрашивает подтверждение действия над процессом
+// data имеет вид "<действие>:<PID>:<время запуска>"
+func (h *CommandHandler) handleProcessConfirm(callback *tgbotapi.CallbackQuery, data string) {
+	parts := strings.SplitN(data, ":", 2)
+	action, ok := processActions[parts[0]]
+	if !ok || len(parts) != 2 {
+		return
+	}
+
+	pid, createTime, err := parseProcessRef(parts[1])
+	if err != nil {
+		h.editProcessMessage(callback, fmt.Sprintf("❌ %v", err), nil)
+		return
+	}
+	proc, err := h.systemService.GetProcess(pid, createTime)
+	if err != nil {
+		h.editProcessMessage(callback, fmt.Sprintf("❌ %v", err), nil)
+		return
+	}
+
+	message := fmt.Sprintf("⚠️ %s процессу %d (%s)?\n%s", action.confirm, proc.PID, proc.Name, proc.Command)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Подтвердить", "proc_do:"+data),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "proc:"+parts[1]),
+		),
+	)
+	h.editProcessMessage(callback, message, &keyboard)
+}
+
+// handleProcessAction выполняет подтвержденное действие над процессом
+// data имеет вид "<действие>:<PID>:<время запуска>"
+func (h *CommandHandler) handleProcessAction(callback *tgbotapi.CallbackQuery, data string) {
+	parts := strings.SplitN(data, ":", 2)
+	if len(parts) != 2 {
+		return
+	}
+	pid, createTime, err := parseProcessRef(parts[1])
+	if err != nil {
+		h.editProcessMessage(callback, fmt.Sprintf("❌ %v", err), nil)
+		return
+	}
+
+	var message string
+	switch parts[0] {
+	case "term", "kill":
+		signal := strings.ToUpper(parts[0])
+		err = h.systemService.SignalProcess(pid, createTime, signal)
+		message = fmt.Sprintf("✅ Процессу %d отправлен SIG%s", pid, signal)
+	case "nice10", "nice19", "nice0":
+		nice, _ := strconv.Atoi(strings.TrimPrefix(parts[0], "nice"))
+		err = h.systemService.ReniceProcess(pid, createTime, nice)
+		message = fmt.Sprintf("✅ Приоритет процесса %d изменен на %d", pid, nice)
+	default:
+		return
+	}
+	if err != nil {
+		message = fmt.Sprintf("❌ %v", err)
+	}
+
+	h.editProcessMessage(callback, message, nil)
+}
+
+// editProcessMessage заменяет текст сообщения с процессом
+func (h *CommandHandler) editProcessMessage(callback *tgbotapi.CallbackQuery, message string, keyboard *tgbotapi.InlineKeyboardMarkup) {
+	editMsg := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, message)
+	editMsg.ReplyMarkup = keyboard
+	h.bot.Send(editMsg)
+}
+
+// formatProcessList форматирует список процессов для /top
+// commandLength ограничивает длину командной строки; 0 означает вывод без командной строки
+func formatProcessList(processes []*system.ProcessInfo, commandLength int) string {
+	result := ""
+	for _, proc := range processes {
+		result += fmt.Sprintf("%d · %s · CPU %.1f%% · RSS %.1f MB · с %s\n",
+			proc.PID, proc.User, proc.CPUPercent, float64(proc.RSS)/(1<<20),
+			proc.StartTime().Format("02.01 15:04"))
+		if commandLength == 0 {
+			continue
+		}
+		command := proc.Command
+		if runes := []rune(command); len(runes) > commandLength {
+			command = string(runes[:commandLength]) + "…"
+		}
+		result += "  " + command + "\n"
+	}
+	return result
+}
+
+// formatTop форматирует списки процессов /top, сокращая командные строки до ограничения Telegram на длину сообщения
+func formatTop(top *system.TopProcesses) string {
+	var message string
+	for _, length := range topCommandLengths {
+		message = "💻 Процессы по CPU\n" + formatProcessList(top.ByCPU, length) +
+			"\n🧠 Процессы по памяти\n" + formatProcessList(top.ByMemory, length)
+		if utf8.RuneCountInString(message) <= telegramMessageLimit {
+			break
+		}
+	}
+	return message
+}
+
+// processRef возвращает идентификатор процесса для callback-данных
+// Время запуска защищает от действий над другим процессом с тем же PID
+func processRef(proc *system.ProcessInfo) string {
+	return fmt.Sprintf("%d:%d", proc.PID, proc.CreateTime)
+}
+
+// parseProcessRef разбирает идентификатор процесса из callback-данных
+func parseProcessRef(ref string) (int32, int64, error) {
+	parts := strings.Split(ref, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("некорректный идентификатор процесса")
+	}
+	pid, err := strconv.ParseInt(parts[0], 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("некорректный PID")
+	}
+	createTime, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("некорректное время запуска процесса")
+	}
+	return int32(pid), createTime, nil
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+
+	"tgbot/internal/services/system"
+)
+
+// statusSensorsLimit количество самых горячих датчиков температуры в /status
+const statusSensorsLimit = 8
+
+// formatSensorsSummary формирует раздел /status с температурой и вентиляторами
+// На виртуальных машинах датчиков обычно нет, тогда раздел не выводится
+func (h *CommandHandler) formatSensorsSummary() string {
+	sensors, err := h.systemService.GetSensors()
+	if err != nil || sensors.Empty() {
+		return ""
+	}
+
+	result := "🌡 Датчики:\n"
+
+	temperatures := append([]system.TemperatureSensor(nil), sensors.Temperatures...)
+	sort.Slice(temperatures, func(i, j int) bool { return temperatures[i].Current > temperatures[j].Current })
+	for i, sensor := range temperatures {
+		if i == statusSensorsLimit {
+			result += fmt.Sprintf("… и еще %d\n", len(temperatures)-statusSensorsLimit)
+			break
+		}
+		result += fmt.Sprintf("%s `%s`: %.1f°C\n", temperatureIcon(sensor), sensor.Name, sensor.Current)
+	}
+
+	for _, fan := range sensors.Fans {
+		// Нулевая скорость обычно означает неподключенный разъем вентилятора
+		if fan.RPM == 0 {
+			continue
+		}
+		result += fmt.Sprintf("🌀 `%s`: %d RPM\n", fan.Name, fan.RPM)
+	}
+	return result + "\n"
+}
+
+// temperatureIcon возвращает индикатор температуры относительно порогов датчика
+func temperatureIcon(sensor system.TemperatureSensor) string {
+	switch {
+	case sensor.Critical > 0 && sensor.Current >= sensor.Critical:
+		return "🔴"
+	case sensor.High > 0 && sensor.Current >= sensor.High:
+		return "🟡"
+	default:
+		return "🟢"
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tgbot/internal/services/system"
+	"tgbot/pkg/schedule"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// handleWho обрабатывает команду /who: отправляет список сеансов новым сообщением
+func (h *CommandHandler) handleWho(chatID int64) {
+	message, keyboard := h.sessionList()
+	msg := tgbotapi.NewMessage(chatID, message)
+	if keyboard != nil {
+		msg.ReplyMarkup = *keyboard
+	}
+	h.bot.Send(msg)
+}
+
+// handleSessionList обновляет список сеансов в текущем сообщении
+func (h *CommandHandler) handleSessionList(callback *tgbotapi.CallbackQuery) {
+	message, keyboard := h.sessionList()
+	h.editSessionMessage(callback, message, keyboard)
+}
+
+// sessionList формирует список сеансов и кнопки завершения
+func (h *CommandHandler) sessionList() (string, *tgbotapi.InlineKeyboardMarkup) {
+	sessions, err := h.systemService.GetSessions()
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err), nil
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	message := "👥 Сеансы пользователей\n\n"
+	if len(sessions) == 0 {
+		message += "Активных сеансов нет\n"
+	}
+	for _, session := range sessions {
+		message += formatSession(session) + "\n"
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("🔌 %s@%s", session.User, session.Terminal), "session_ask:"+sessionRef(session)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔄 Обновить", "session_list"),
+	))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return message, &keyboard
+}
+
+// handleSessionConfirm запрашивает подтверждение завершения сеанса
+// ref имеет вид "<терминал>:<время входа>"
+func (h *CommandHandler) handleSessionConfirm(callback *tgbotapi.CallbackQuery, ref string) {
+	terminal, started, err := parseSessionRef(ref)
+	if err != nil {
+		h.editSessionMessage(callback, fmt.Sprintf("❌ %v", err), nil)
+		return
+	}
+
+	sessions, err := h.systemService.GetSessions()
+	if err != nil {
+		h.editSessionMessage(callback, fmt.Sprintf("❌ %v", err), nil)
+		return
+	}
+	var session *system.Session
+	for i := range sessions {
+		if sessions[i].Terminal == terminal && sessions[i].Started.Unix() == started {
+			session = &sessions[i]
+			break
+		}
+	}
+	if session == nil {
+		h.handleSessionList(callback)
+		return
+	}
+
+	message := fmt.Sprintf("⚠️ Завершить сеанс %s на %s?\nВсе процессы терминала получат SIGKILL.\n\n%s",
+		session.User, session.Terminal, formatSession(*session))
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Подтвердить", "session_do:"+ref),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "session_list"),
+		),
+	)
+	h.editSessionMessage(callback, message, &keyboard)
+}
+
+// handleSessionAction завершает подтвержденный сеанс
+// ref имеет вид "<терминал>:<время входа>"
+func (h *CommandHandler) handleSessionAction(callback *tgbotapi.CallbackQuery, ref string) {
+	terminal, started, err := parseSessionRef(ref)
+	if err != nil {
+		h.editSessionMessage(callback, fmt.Sprintf("❌ %v", err), nil)
+		return
+	}
+
+	message := fmt.Sprintf("✅ Сеанс на %s завершен", terminal)
+	if err := h.systemService.TerminateSession(terminal, started); err != nil {
+		message = fmt.Sprintf("❌ %v", err)
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("👥 Сеансы", "session_list"),
+		),
+	)
+	h.editSessionMessage(callback, message, &keyboard)
+}
+
+// editSessionMessage заменяет текст сообщения со списком сеансов
+func (h *CommandHandler) editSessionMessage(callback *tgbotapi.CallbackQuery, message string, keyboard *tgbotapi.InlineKeyboardMarkup) {
+	editMsg := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, message)
+	editMsg.ReplyMarkup = keyboard
+	h.bot.Send(editMsg)
+}
+
+// formatSession форматирует сеанс для /who
+func formatSession(session system.Session) string {
+	source := session.Host
+	if source == "" {
+		source = "локально"
+	}
+	idle := "?"
+	if session.Idle >= 0 {
+		idle = schedule.FormatDuration(session.Idle)
+	}
+	return fmt.Sprintf("%s · %s · %s\n  вход %s · бездействие %s",
+		session.User, session.Terminal, source, session.Started.Format("02.01 15:04"), idle)
+}
+
+// sessionRef возвращает идентификатор сеанса для callback-данных
+// Время входа защищает от завершения другого сеанса на том же терминале
+func sessionRef(session system.Session) string {
+	return fmt.Sprintf("%s:%d", session.Terminal, session.Started.Unix())
+}
+
+// parseSessionRef разбирает идентификатор сеанса из callback-данных
+func parseSessionRef(ref string) (string, int64, error) {
+	idx := strings.LastIndex(ref, ":")
+	if idx <= 0 {
+		return "", 0, fmt.Errorf("некорректный идентификатор сеанса")
+	}
+	started, err := strconv.ParseInt(ref[idx+1:], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("некорректное время входа")
+	}
+	return ref[:idx], started, nil
+}
@@ -0,0 +1,491 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tgbot/internal/services/system"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// maxPackageListLength ограничение длины списка пакетов в символах, чтобы сообщение уместилось в лимит Telegram
+const maxPackageListLength = 2000
+
+// Режимы обновления системы
+const (
+	upgradeModeAll      = "all"
+	upgradeModeSecurity = "security"
+	upgradeModeSelected = "selected"
+)
+
+// upgradeProgressInterval период обновления сообщения с прогрессом
+const upgradeProgressInterval = 3 * time.Second
+
+// maxSelectablePackages ограничивает количество кнопок выбора пакетов
+const maxSelectablePackages = 30
+
+// updateSession результат последней проверки обновлений в чате
+type updateSession struct {
+	packages []system.UpgradablePackage
+	selected map[int]bool
+}
+
+// upgradePreview список пакетов, показанный в предпросмотре обновления
+// Подтверждение выполняет именно этот список, даже если выбор пакетов изменился после предпросмотра
+type upgradePreview struct {
+	id       string
+	mode     string
+	packages []string
+}
+
+// handleCheckUpdates проверяет доступные обновления и показывает варианты обновления
+func (h *CommandHandler) handleCheckUpdates(callback *tgbotapi.CallbackQuery) {
+	chatID := callback.Message.Chat.ID
+
+	message := "🔍 Проверяю доступные обновления..."
+	msg := tgbotapi.NewMessage(chatID, message)
+	h.bot.Send(msg)
+
+	// Выполняем проверку обновлений
+	packages, err := h.systemService.CheckUpdates()
+	if err != nil {
+		message = fmt.Sprintf("❌ Ошибка проверки обновлений: %v", err)
+		msg = tgbotapi.NewMessage(chatID, message)
+		h.bot.Send(msg)
+		return
+	}
+
+	if len(packages) == 0 {
+		// Если обновлений нет, выводим сообщение и возвращаем к основному меню
+		message = "✅ Все обновления установлены!"
+		msg = tgbotapi.NewMessage(chatID, message)
+		h.bot.Send(msg)
+
+		// Создаем фиктивный update для возврата к основному меню
+		fakeUpdate := tgbotapi.Update{
+			Message: &tgbotapi.Message{
+				Chat: callback.Message.Chat,
+				Text: "/start",
+			},
+		}
+		h.handleStart(fakeUpdate)
+		return
+	}
+
+	// Сохраняем результат проверки для выбора пакетов
+	h.mu.Lock()
+	h.updateSessions[chatID] = &updateSession{
+		packages: packages,
+		selected: make(map[int]bool),
+	}
+	h.mu.Unlock()
+
+	// Создание inline клавиатуры с вариантами обновления
+	buttons := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⬆️ Обновить всё", "upgrade_preview:"+upgradeModeAll),
+		),
+	}
+	if countSecurityUpdates(packages) > 0 {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔒 Только безопасность", "upgrade_preview:"+upgradeModeSecurity),
+		))
+	}
+	buttons = append(buttons,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("☑️ Выбрать пакеты", "upgrade_select"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", "back_to_main"),
+		),
+	)
+
+	msg = tgbotapi.NewMessage(chatID, h.formatUpdates(packages))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	if _, err := h.bot.Send(msg); err != nil {
+		log.Printf("Handler: Ошибка отправки списка обновлений: %v", err)
+	}
+}
+
+// handleUpgradeSelect показывает список пакетов с переключателями выбора
+func (h *CommandHandler) handleUpgradeSelect(callback *tgbotapi.CallbackQuery) {
+	session := h.getUpdateSession(callback.Message.Chat.ID)
+	if session == nil {
+		h.sendUpdateSessionExpired(callback)
+		return
+	}
+
+	message := "☑️ *Выбор пакетов для обновления*\n\nНажмите на пакет, чтобы отметить его:"
+	if len(session.packages) > maxSelectablePackages {
+		message += fmt.Sprintf("\n_Показаны первые %d пакетов из %d_", maxSelectablePackages, len(session.packages))
+	}
+
+	editMsg := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, message)
+	editMsg.ParseMode = "Markdown"
+	keyboard := h.createPackageSelectKeyboard(session)
+	editMsg.ReplyMarkup = &keyboard
+
+	h.bot.Send(editMsg)
+}
+
+// handlePackageToggle переключает выбор пакета и обновляет клавиатуру
+func (h *CommandHandler) handlePackageToggle(callback *tgbotapi.CallbackQuery, indexStr string) {
+	session := h.getUpdateSession(callback.Message.Chat.ID)
+	index, err := strconv.Atoi(indexStr)
+	if session == nil || err != nil || index < 0 || index >= len(session.packages) {
+		h.sendUpdateSessionExpired(callback)
+		return
+	}
+
+	h.mu.Lock()
+	session.selected[index] = !session.selected[index]
+	h.mu.Unlock()
+
+	keyboard := h.createPackageSelectKeyboard(session)
+	editMarkup := tgbotapi.NewEditMessageReplyMarkup(callback.Message.Chat.ID, callback.Message.MessageID, keyboard)
+	h.bot.Send(editMarkup)
+}
+
+// handleUpgradePreview выполняет пробное обновление и запрашивает подтверждение
+func (h *CommandHandler) handleUpgradePreview(callback *tgbotapi.CallbackQuery, mode string) {
+	chatID := callback.Message.Chat.ID
+
+	packages, ok := h.packagesForMode(chatID, mode)
+	if !ok {
+		h.sendUpdateSessionExpired(callback)
+		return
+	}
+	if mode != upgradeModeAll && len(packages) == 0 {
+		msg := tgbotapi.NewMessage(chatID, "⚠️ Не выбрано ни одного пакета для обновления")
+		h.bot.Send(msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "👁 Выполняю пробное обновление...")
+	h.bot.Send(msg)
+
+	plan, err := h.systemService.PreviewUpgrade(packages...)
+	if err != nil {
+		msg = tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Ошибка пробного обновления: %v", err))
+		h.bot.Send(msg)
+		return
+	}
+
+	if plan.Empty() {
+		msg = tgbotapi.NewMessage(chatID, "✅ Нет пакетов для обновления")
+		h.bot.Send(msg)
+		return
+	}
+
+	preview := &upgradePreview{
+		id:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		mode:     mode,
+		packages: packages,
+	}
+	h.mu.Lock()
+	h.upgradePreviews[chatID] = preview
+	h.mu.Unlock()
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Подтвердить обновление", "upgrade_confirm:"+preview.id),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "back_to_main"),
+		),
+	)
+
+	msg = tgbotapi.NewMessage(chatID, formatUpgradePlan(plan))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = keyboard
+	if _, err := h.bot.Send(msg); err != nil {
+		log.Printf("Handler: Ошибка отправки плана обновления: %v", err)
+	}
+}
+
+// handleUpgradeConfirm запускает подтвержденное обновление в фоне
+// previewID идентифицирует предпросмотр, список пакетов которого будет обновлен
+func (h *CommandHandler) handleUpgradeConfirm(callback *tgbotapi.CallbackQuery, previewID string) {
+	chatID := callback.Message.Chat.ID
+
+	h.mu.Lock()
+	preview := h.upgradePreviews[chatID]
+	h.mu.Unlock()
+	// Устаревшая кнопка подтверждения не должна запускать обновление
+	if preview == nil || preview.id != previewID {
+		h.sendUpdateSessionExpired(callback)
+		return
+	}
+	// Пустой список пакетов означает для пакетного менеджера обновление всей системы
+	if preview.mode != upgradeModeAll && len(preview.packages) == 0 {
+		msg := tgbotapi.NewMessage(chatID, "⚠️ Не выбрано ни одного пакета для обновления")
+		h.bot.Send(msg)
+		return
+	}
+
+	job, err := h.systemService.StartUpgrade(preview.packages...)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Ошибка запуска обновления: %v", err))
+		h.bot.Send(msg)
+		return
+	}
+
+	// Список обновлений устареет после установки
+	h.mu.Lock()
+	delete(h.updateSessions, chatID)
+	delete(h.upgradePreviews, chatID)
+	h.mu.Unlock()
+
+	msg := tgbotapi.NewMessage(chatID, formatUpgradeProgress(job))
+	sent, err := h.bot.Send(msg)
+	if err != nil {
+		return
+	}
+
+	go h.trackUpgradeJob(chatID, sent.MessageID, job)
+}
+
+// handleUpgradeLog отправляет полный журнал обновления файлом
+func (h *CommandHandler) handleUpgradeLog(callback *tgbotapi.CallbackQuery, jobID string) {
+	chatID := callback.Message.Chat.ID
+
+	data, err := h.systemService.GetUpgradeLog(jobID)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Журнал обновления недоступен: %v", err))
+		h.bot.Send(msg)
+		return
+	}
+
+	doc := tgbotapi.NewDocumentUpload(chatID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("upgrade-%s.log", jobID),
+		Bytes: data,
+	})
+	h.bot.Send(doc)
+}
+
+// trackUpgradeJob периодически обновляет сообщение с прогрессом до завершения задачи
+func (h *CommandHandler) trackUpgradeJob(chatID int64, messageID int, job *system.UpgradeJob) {
+	ticker := time.NewTicker(upgradeProgressInterval)
+	defer ticker.Stop()
+
+	lastText := ""
+	for {
+		select {
+		case <-ticker.C:
+			// Редактируем сообщение только при изменении, иначе Telegram вернет ошибку
+			text := formatUpgradeProgress(job)
+			if text != lastText {
+				h.bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, text))
+				lastText = text
+			}
+		case <-job.Done():
+			keyboard := tgbotapi.NewInlineKeyboardMarkup(
+				tgbotapi.NewInlineKeyboardRow(
+					tgbotapi.NewInlineKeyboardButtonData("📄 Журнал обновления", "upgrade_log:"+job.ID),
+				),
+			)
+			editMsg := tgbotapi.NewEditMessageText(chatID, messageID, formatUpgradeProgress(job))
+			editMsg.ReplyMarkup = &keyboard
+			h.bot.Send(editMsg)
+			return
+		}
+	}
+}
+
+// packagesForMode возвращает список пакетов для режима обновления
+// Для обновления всей системы список пуст; false означает, что результат проверки устарел
+func (h *CommandHandler) packagesForMode(chatID int64, mode string) ([]string, bool) {
+	if mode == upgradeModeAll {
+		return nil, true
+	}
+
+	session := h.getUpdateSession(chatID)
+	if session == nil {
+		return nil, false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var packages []string
+	for i, pkg := range session.packages {
+		if (mode == upgradeModeSecurity && pkg.Security) || (mode == upgradeModeSelected && session.selected[i]) {
+			packages = append(packages, pkg.Name)
+		}
+	}
+	return packages, true
+}
+
+// getUpdateSession возвращает результат последней проверки обновлений в чате
+func (h *CommandHandler) getUpdateSession(chatID int64) *updateSession {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.updateSessions[chatID]
+}
+
+// sendUpdateSessionExpired сообщает, что нужно заново проверить обновления
+func (h *CommandHandler) sendUpdateSessionExpired(callback *tgbotapi.CallbackQuery) {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔍 Проверить обновления", "check_updates"),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID, "⚠️ Список обновлений устарел, выполните проверку заново")
+	msg.ReplyMarkup = keyboard
+	h.bot.Send(msg)
+}
+
+// createPackageSelectKeyboard создает клавиатуру с переключателями пакетов
+func (h *CommandHandler) createPackageSelectKeyboard(session *updateSession) tgbotapi.InlineKeyboardMarkup {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buttons := make([][]tgbotapi.InlineKeyboardButton, 0)
+	for i, pkg := range session.packages {
+		if i >= maxSelectablePackages {
+			break
+		}
+
+		mark := "⬜"
+		if session.selected[i] {
+			mark = "✅"
+		}
+		label := fmt.Sprintf("%s %s", mark, pkg.Name)
+		if pkg.Security {
+			label += " 🔒"
+		}
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("pkg_toggle:%d", i)),
+		))
+	}
+
+	buttons = append(buttons,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("👁 Предпросмотр", "upgrade_preview:"+upgradeModeSelected),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", "back_to_main"),
+		),
+	)
+
+	return tgbotapi.NewInlineKeyboardMarkup(buttons...)
+}
+
+// formatUpdates формирует сообщение со списком доступных обновлений
+func (h *CommandHandler) formatUpdates(packages []system.UpgradablePackage) string {
+	lines := make([]string, 0, len(packages))
+	for _, pkg := range packages {
+		marker := "📦"
+		if pkg.Security {
+			marker = "🔒"
+		}
+
+		line := fmt.Sprintf("%s %s %s → %s", marker, pkg.Name, pkg.Current, pkg.Candidate)
+		if pkg.Current == "" {
+			line = fmt.Sprintf("%s %s → %s", marker, pkg.Name, pkg.Candidate)
+		}
+		if pkg.Repo != "" {
+			line += fmt.Sprintf(" (%s)", pkg.Repo)
+		}
+		lines = append(lines, line)
+	}
+	list := truncateLines(lines, maxPackageListLength)
+
+	return fmt.Sprintf("🔍 *Доступные обновления (%s): %d*\n🔒 Обновления безопасности: %d\n```\n%s```",
+		h.systemService.PackageManagerName(), len(packages), countSecurityUpdates(packages), list)
+}
+
+// formatUpgradeProgress формирует сообщение о состоянии задачи обновления
+func formatUpgradeProgress(job *system.UpgradeJob) string {
+	progress := job.Progress()
+	elapsed := progress.Elapsed.Round(time.Second)
+
+	if !progress.Done {
+		message := fmt.Sprintf("⬆️ Обновление системы (%s)\n\nЭтап: %s\n", job.Manager, progress.Phase)
+		if progress.Package != "" {
+			message += fmt.Sprintf("Пакет: %s\n", progress.Package)
+		}
+		return message + fmt.Sprintf("Прошло: %s", elapsed)
+	}
+
+	var message string
+	if progress.Err != nil {
+		message = fmt.Sprintf("❌ %v\nПоследняя строка вывода: %s\n", progress.Err, progress.LastLine)
+	} else {
+		message = fmt.Sprintf("✅ Система успешно обновлена за %s\n", elapsed)
+	}
+
+	if report := progress.Report; report != nil {
+		if len(report.HeldBack) > 0 {
+			message += fmt.Sprintf("\n⏸ Отложенные пакеты: %s", strings.Join(report.HeldBack, ", "))
+		}
+		if len(report.ServicesToRestart) > 0 {
+			message += fmt.Sprintf("\n🔄 Требуют перезапуска: %s", strings.Join(report.ServicesToRestart, ", "))
+		}
+		if report.RebootRequired {
+			message += "\n⚠️ Требуется перезагрузка сервера"
+		} else {
+			message += "\n✅ Перезагрузка не требуется"
+		}
+	}
+
+	return message
+}
+
+// formatUpgradePlan формирует сообщение с планом обновления
+func formatUpgradePlan(plan *system.UpgradePlan) string {
+	// Удаляемые пакеты выводятся первыми, чтобы при обрезке длинного списка они не пропали из предпросмотра
+	var lines []string
+	lines = append(lines, formatPackageChanges("➖ Будет удалено", plan.Remove)...)
+	lines = append(lines, formatPackageChanges("⬆️ Будет обновлено", plan.Upgrade)...)
+	lines = append(lines, formatPackageChanges("➕ Будет установлено", plan.Install)...)
+	list := truncateLines(lines, maxPackageListLength)
+
+	return fmt.Sprintf("👁 *Предпросмотр обновления*\n```\n%s```\nПодтвердите выполнение обновления:", list)
+}
+
+// formatPackageChanges формирует раздел плана обновления
+func formatPackageChanges(title string, changes []system.PackageChange) []string {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	lines := []string{fmt.Sprintf("%s (%d):", title, len(changes))}
+	for _, change := range changes {
+		lines = append(lines, fmt.Sprintf("  %s %s", change.Name, change.Version))
+	}
+	return append(lines, "")
+}
+
+// truncateLines объединяет строки, пока их длина в символах не превысит limit; об остальных сообщает "… и еще N"
+// Обрезка по целым строкам не разрывает многобайтовые символы, иначе Telegram отклоняет сообщение
+func truncateLines(lines []string, limit int) string {
+	result := ""
+	length := 0
+	for i, line := range lines {
+		length += utf8.RuneCountInString(line) + 1
+		if length > limit {
+			return result + fmt.Sprintf("… и еще %d\n", len(lines)-i)
+		}
+		result += line + "\n"
+	}
+	return result
+}
+
+// countSecurityUpdates подсчитывает количество обновлений безопасности
+func countSecurityUpdates(packages []system.UpgradablePackage) int {
+	count := 0
+	for _, pkg := range packages {
+		if pkg.Security {
+			count++
+		}
+	}
+	return count
+}
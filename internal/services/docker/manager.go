@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -22,6 +23,11 @@ type Container struct {
 	Created time.Time
 }
 
+// Running проверяет, запущен ли контейнер
+func (c Container) Running() bool {
+	return strings.HasPrefix(c.Status, "Up")
+}
+
 // NewManager создает новый менеджер Docker
 func NewManager(socket string) (*Manager, error) {
 	// Проверка доступности Docker
@@ -172,3 +178,115 @@ func (m *Manager) GetContainerStatus(id string) (string, error) {
 
 	return status, nil
 }
+
+// GetRestartCounts возвращает количество автоматических перезапусков контейнеров по именам
+func (m *Manager) GetRestartCounts() (map[string]int, error) {
+	containers, err := m.ListContainers()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(containers))
+	if len(containers) == 0 {
+		return counts, nil
+	}
+
+	args := []string{"docker", "inspect", "--format", "{{.Name}} {{.RestartCount}}"}
+	for _, container := range containers {
+		args = append(args, container.ID)
+	}
+	output, err := exec.Command("sudo", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения информации о контейнерах: %v", err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		var count int
+		if _, err := fmt.Sscanf(fields[1], "%d", &count); err == nil {
+			counts[strings.TrimPrefix(fields[0], "/")] = count
+		}
+	}
+	return counts, nil
+}
+
+// GetRootDir возвращает каталог данных Docker
+func (m *Manager) GetRootDir() (string, error) {
+	output, err := exec.Command("sudo", "docker", "info", "--format", "{{.DockerRootDir}}").Output()
+	if err != nil {
+		return "", fmt.Errorf("ошибка получения каталога данных Docker: %v", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetDiskUsage возвращает сводку места, занятого образами, контейнерами, томами и кэшем сборки
+func (m *Manager) GetDiskUsage() (string, error) {
+	output, err := exec.Command("sudo", "docker", "system", "df").Output()
+	if err != nil {
+		return "", fmt.Errorf("ошибка получения места, занятого Docker: %v", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// PruneEstimate возвращает место, освобождаемое командой Prune
+// Тома Prune не удаляет, поэтому они не учитываются
+func (m *Manager) PruneEstimate() (uint64, error) {
+	output, err := exec.Command("sudo", "docker", "system", "df", "--format", "{{.Type}}\t{{.Reclaimable}}").Output()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка получения места, занятого Docker: %v", err)
+	}
+
+	var total uint64
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		// Images	1.234GB (45%)
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) != 2 || strings.Contains(parts[0], "Volumes") {
+			continue
+		}
+		fields := strings.Fields(parts[1])
+		if len(fields) == 0 {
+			continue
+		}
+		total += parseDockerSize(fields[0])
+	}
+	return total, nil
+}
+
+// Prune удаляет остановленные контейнеры, неиспользуемые сети, образы без контейнеров и кэш сборки
+// Возвращает освобожденное место по данным Docker
+func (m *Manager) Prune() (string, error) {
+	output, err := exec.Command("sudo", "docker", "system", "prune", "-a", "-f").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("ошибка очистки Docker: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "Total reclaimed space:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "Total reclaimed space:")), nil
+		}
+	}
+	return "", nil
+}
+
+// parseDockerSize разбирает размер в формате Docker (десятичные единицы: 512B, 1.5kB, 300MB, 1.2GB)
+func parseDockerSize(value string) uint64 {
+	units := []struct {
+		suffix     string
+		multiplier float64
+	}{
+		{"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"kB", 1e3}, {"B", 1},
+	}
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			number, err := strconv.ParseFloat(strings.TrimSuffix(value, unit.suffix), 64)
+			if err != nil {
+				return 0
+			}
+			return uint64(number * unit.multiplier)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,314 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tgbot/internal/services/docker"
+	"tgbot/internal/services/monitoring"
+	"tgbot/internal/services/system"
+	"tgbot/pkg/config"
+)
+
+// metricPrefix префикс имен всех экспортируемых метрик
+const metricPrefix = "tgbot_"
+
+// Exporter HTTP сервер, отдающий метрики в текстовом формате Prometheus
+type Exporter struct {
+	config        config.ExporterConfig
+	systemService *system.Monitor
+	dockerService *docker.Manager
+	monitoring    *monitoring.Service
+	apiStats      *APIStats
+	commandStats  *CommandStats
+	startedAt     time.Time
+	server        *http.Server
+}
+
+// NewExporter создает экспортер метрик
+func NewExporter(cfg config.ExporterConfig, systemService *system.Monitor, dockerService *docker.Manager, monitoringService *monitoring.Service, apiStats *APIStats, commandStats *CommandStats) *Exporter {
+	return &Exporter{
+		config:        cfg,
+		systemService: systemService,
+		dockerService: dockerService,
+		monitoring:    monitoringService,
+		apiStats:      apiStats,
+		commandStats:  commandStats,
+		startedAt:     time.Now(),
+	}
+}
+
+// Start запускает HTTP сервер экспортера
+func (e *Exporter) Start() {
+	path := e.config.Path
+	if path == "" {
+		path = "/metrics"
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, e.handleMetrics)
+	e.server = &http.Server{
+		Addr:              e.config.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		log.Printf("Exporter: Метрики доступны на %s%s", e.config.Listen, path)
+		if err := e.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Exporter: Ошибка HTTP сервера: %v", err)
+		}
+	}()
+}
+
+// Stop останавливает HTTP сервер экспортера
+func (e *Exporter) Stop() {
+	if e.server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.server.Shutdown(ctx); err != nil {
+		log.Printf("Exporter: Ошибка остановки HTTP сервера: %v", err)
+	}
+}
+
+// handleMetrics отдает текущие значения метрик
+func (e *Exporter) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var out writer
+	e.writeHost(&out)
+	e.writeContainers(&out)
+	e.writeAlerts(&out)
+	e.writeBot(&out)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(out.buf.Bytes())
+}
+
+// writeHost записывает метрики хоста
+func (e *Exporter) writeHost(out *writer) {
+	if cpuInfo, err := e.systemService.GetCPUInfo(); err == nil {
+		out.gauge("host_cpu_usage_percent", "Загрузка CPU в процентах", nil, cpuInfo.Load)
+		out.gauge("host_cpu_iowait_percent", "Ожидание ввода-вывода в процентах времени CPU", nil, cpuInfo.IOWait)
+		out.gauge("host_cpu_steal_percent", "Время CPU, отобранное гипервизором, в процентах", nil, cpuInfo.Steal)
+		out.gauge("host_cpu_cores", "Количество логических ядер", nil, float64(cpuInfo.Cores))
+		out.gauge("host_context_switches_per_second", "Переключения контекста в секунду", nil, cpuInfo.ContextSwitches)
+		out.header("host_cpu_core_usage_percent", "Загрузка ядра CPU в процентах", "gauge")
+		for i, usage := range cpuInfo.PerCore {
+			out.sample("host_cpu_core_usage_percent", labels{"core", strconv.Itoa(i)}, usage)
+		}
+	}
+
+	if loadInfo, err := e.systemService.GetLoadAverage(); err == nil {
+		out.gauge("host_load1", "Средняя загрузка за 1 минуту", nil, loadInfo.Load1)
+		out.gauge("host_load5", "Средняя загрузка за 5 минут", nil, loadInfo.Load5)
+		out.gauge("host_load15", "Средняя загрузка за 15 минут", nil, loadInfo.Load15)
+	}
+
+	if memInfo, err := e.systemService.GetMemoryInfo(); err == nil {
+		out.gauge("host_memory_total_bytes", "Объем оперативной памяти", nil, float64(memInfo.TotalBytes))
+		out.gauge("host_memory_used_bytes", "Используемая оперативная память", nil, float64(memInfo.UsedBytes))
+		out.gauge("host_memory_available_bytes", "Доступная оперативная память", nil, float64(memInfo.AvailableBytes))
+		out.gauge("host_swap_total_bytes", "Объем swap", nil, float64(memInfo.SwapTotalBytes))
+		out.gauge("host_swap_used_bytes", "Используемый swap", nil, float64(memInfo.SwapUsedBytes))
+	}
+
+	if diskInfos, err := e.systemService.GetDiskInfo(); err == nil {
+		out.header("host_disk_total_bytes", "Размер файловой системы", "gauge")
+		for _, diskInfo := range diskInfos {
+			out.sample("host_disk_total_bytes", labels{"mountpoint", diskInfo.MountPoint, "fstype", diskInfo.FileSystem}, float64(diskInfo.TotalBytes))
+		}
+		out.header("host_disk_used_bytes", "Занятое место в файловой системе", "gauge")
+		for _, diskInfo := range diskInfos {
+			out.sample("host_disk_used_bytes", labels{"mountpoint", diskInfo.MountPoint, "fstype", diskInfo.FileSystem}, float64(diskInfo.UsedBytes))
+		}
+		out.header("host_disk_free_bytes", "Свободное место в файловой системе", "gauge")
+		for _, diskInfo := range diskInfos {
+			out.sample("host_disk_free_bytes", labels{"mountpoint", diskInfo.MountPoint, "fstype", diskInfo.FileSystem}, float64(diskInfo.FreeBytes))
+		}
+		out.header("host_disk_inodes_total", "Количество inode файловой системы", "gauge")
+		for _, diskInfo := range diskInfos {
+			out.sample("host_disk_inodes_total", labels{"mountpoint", diskInfo.MountPoint, "fstype", diskInfo.FileSystem}, float64(diskInfo.InodesTotal))
+		}
+		out.header("host_disk_inodes_used", "Занятые inode файловой системы", "gauge")
+		for _, diskInfo := range diskInfos {
+			out.sample("host_disk_inodes_used", labels{"mountpoint", diskInfo.MountPoint, "fstype", diskInfo.FileSystem}, float64(diskInfo.InodesUsed))
+		}
+	}
+
+	if counters, err := e.systemService.GetNetCounters(); err == nil {
+		out.header("host_network_receive_bytes_total", "Принято байт сетевым интерфейсом", "counter")
+		for _, counter := range counters {
+			out.sample("host_network_receive_bytes_total", labels{"interface", counter.Name}, float64(counter.BytesRecv))
+		}
+		out.header("host_network_transmit_bytes_total", "Отправлено байт сетевым интерфейсом", "counter")
+		for _, counter := range counters {
+			out.sample("host_network_transmit_bytes_total", labels{"interface", counter.Name}, float64(counter.BytesSent))
+		}
+	}
+
+	// Без датчиков (например, на VPS) метрики не выводятся
+	if sensors, err := e.systemService.GetSensors(); err == nil {
+		if len(sensors.Temperatures) > 0 {
+			out.header("host_temperature_celsius", "Температура датчика", "gauge")
+			for _, sensor := range sensors.Temperatures {
+				out.sample("host_temperature_celsius", labels{"sensor", sensor.Name}, sensor.Current)
+			}
+		}
+		if len(sensors.Fans) > 0 {
+			out.header("host_fan_rpm", "Скорость вентилятора", "gauge")
+			for _, fan := range sensors.Fans {
+				out.sample("host_fan_rpm", labels{"fan", fan.Name}, float64(fan.RPM))
+			}
+		}
+	}
+
+	if uptime, _, err := e.systemService.GetUptime(); err == nil {
+		out.gauge("host_uptime_seconds", "Время работы системы", nil, uptime.Seconds())
+	}
+
+	out.gauge("host_reboot_required", "Требуется перезагрузка после обновлений", nil, boolValue(e.systemService.GetRebootStatus().Required))
+}
+
+// writeContainers записывает состояние контейнеров Docker
+func (e *Exporter) writeContainers(out *writer) {
+	containers, err := e.dockerService.ListContainers()
+	out.gauge("docker_up", "Доступность Docker", nil, boolValue(err == nil))
+	if err != nil {
+		return
+	}
+
+	out.header("container_running", "Контейнер запущен", "gauge")
+	for _, container := range containers {
+		out.sample("container_running", labels{"name", container.Name, "image", container.Image}, boolValue(container.Running()))
+	}
+
+	if counts, err := e.dockerService.GetRestartCounts(); err == nil {
+		names := make([]string, 0, len(counts))
+		for name := range counts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		out.header("container_restarts_total", "Количество автоматических перезапусков контейнера", "counter")
+		for _, name := range names {
+			out.sample("container_restarts_total", labels{"name", name}, float64(counts[name]))
+		}
+	}
+}
+
+// writeAlerts записывает состояние алертов
+func (e *Exporter) writeAlerts(out *writer) {
+	silenced := make(map[string]bool)
+	for _, silence := range e.monitoring.Silences() {
+		silenced[silence.Key] = true
+	}
+
+	alerts := e.monitoring.Alerts()
+	out.header("alert_state", "Состояние алерта: 0 - норма, 1 - ожидание, 2 - активен", "gauge")
+	for _, alert := range alerts {
+		out.sample("alert_state", labels{"alert", alert.Key, "severity", alert.Severity}, float64(alert.State))
+	}
+	out.header("alert_silenced", "Уведомления по алерту заглушены", "gauge")
+	for _, alert := range alerts {
+		out.sample("alert_silenced", labels{"alert", alert.Key}, boolValue(silenced[alert.Key]))
+	}
+	out.header("alert_acknowledged", "Активный алерт подтвержден", "gauge")
+	for _, alert := range alerts {
+		out.sample("alert_acknowledged", labels{"alert", alert.Key}, boolValue(alert.AcknowledgedBy != ""))
+	}
+}
+
+// writeBot записывает метрики работы бота
+func (e *Exporter) writeBot(out *writer) {
+	out.gauge("bot_start_time_seconds", "Время запуска бота (Unix)", nil, float64(e.startedAt.Unix()))
+
+	if e.apiStats != nil {
+		requests, errors := e.apiStats.snapshot()
+		methods := make([]string, 0, len(requests))
+		for method := range requests {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		out.header("telegram_requests_total", "Запросы к Telegram API", "counter")
+		for _, method := range methods {
+			out.sample("telegram_requests_total", labels{"method", method}, float64(requests[method]))
+		}
+		out.header("telegram_errors_total", "Ошибки запросов к Telegram API", "counter")
+		for _, method := range methods {
+			out.sample("telegram_errors_total", labels{"method", method}, float64(errors[method]))
+		}
+	}
+
+	if e.commandStats != nil {
+		kinds, histograms := e.commandStats.snapshot()
+		out.header("command_duration_seconds", "Время обработки команд и нажатий кнопок", "histogram")
+		for _, kind := range kinds {
+			h := histograms[kind]
+			for i, bound := range commandBuckets {
+				out.sample("command_duration_seconds_bucket", labels{"type", kind, "le", strconv.FormatFloat(bound, 'g', -1, 64)}, float64(h.buckets[i]))
+			}
+			out.sample("command_duration_seconds_bucket", labels{"type", kind, "le", "+Inf"}, float64(h.count))
+			out.sample("command_duration_seconds_sum", labels{"type", kind}, h.sum)
+			out.sample("command_duration_seconds_count", labels{"type", kind}, float64(h.count))
+		}
+	}
+}
+
+// labels пары имя-значение меток метрики
+type labels []string
+
+// writer формирует ответ в текстовом формате Prometheus
+type writer struct {
+	buf bytes.Buffer
+}
+
+// header записывает описание и тип метрики
+func (w *writer) header(name, help, kind string) {
+	fmt.Fprintf(&w.buf, "# HELP %s%s %s\n# TYPE %s%s %s\n", metricPrefix, name, help, metricPrefix, name, kind)
+}
+
+// gauge записывает метрику типа gauge с одним значением
+func (w *writer) gauge(name, help string, l labels, value float64) {
+	w.header(name, help, "gauge")
+	w.sample(name, l, value)
+}
+
+// sample записывает значение метрики с метками
+func (w *writer) sample(name string, l labels, value float64) {
+	w.buf.WriteString(metricPrefix + name)
+	if len(l) > 0 {
+		w.buf.WriteByte('{')
+		for i := 0; i+1 < len(l); i += 2 {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			fmt.Fprintf(&w.buf, "%s=\"%s\"", l[i], escapeLabel(l[i+1]))
+		}
+		w.buf.WriteByte('}')
+	}
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	w.buf.WriteByte('\n')
+}
+
+// escapeLabel экранирует значение метки
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// boolValue переводит логическое значение в 0 или 1
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
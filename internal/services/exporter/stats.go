@@ -0,0 +1,119 @@
+package exporter
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// commandBuckets границы гистограммы времени обработки команд в секундах
+var commandBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// APIStats транспорт HTTP, подсчитывающий запросы к Telegram API и ошибки по методам
+type APIStats struct {
+	base     http.RoundTripper
+	requests map[string]uint64
+	errors   map[string]uint64
+	mu       sync.Mutex
+}
+
+// NewAPIStats создает транспорт со счетчиками поверх base; nil означает транспорт по умолчанию
+func NewAPIStats(base http.RoundTripper) *APIStats {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &APIStats{
+		base:     base,
+		requests: make(map[string]uint64),
+		errors:   make(map[string]uint64),
+	}
+}
+
+// RoundTrip выполняет запрос и учитывает его результат
+func (a *APIStats) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := a.base.RoundTrip(req)
+
+	// Путь имеет вид /bot<токен>/<метод>; в метрики попадает только метод
+	method := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+
+	a.mu.Lock()
+	a.requests[method]++
+	if err != nil || resp.StatusCode >= http.StatusBadRequest {
+		a.errors[method]++
+	}
+	a.mu.Unlock()
+
+	return resp, err
+}
+
+// snapshot возвращает копии счетчиков запросов и ошибок
+func (a *APIStats) snapshot() (map[string]uint64, map[string]uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	requests := make(map[string]uint64, len(a.requests))
+	for method, count := range a.requests {
+		requests[method] = count
+	}
+	errors := make(map[string]uint64, len(a.errors))
+	for method, count := range a.errors {
+		errors[method] = count
+	}
+	return requests, errors
+}
+
+// histogram накопленная гистограмма длительностей
+type histogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// CommandStats гистограммы времени обработки входящих обновлений по типам
+type CommandStats struct {
+	histograms map[string]*histogram
+	mu         sync.Mutex
+}
+
+// NewCommandStats создает статистику обработки команд
+func NewCommandStats() *CommandStats {
+	return &CommandStats{histograms: make(map[string]*histogram)}
+}
+
+// Observe учитывает время обработки обновления указанного типа
+func (c *CommandStats) Observe(kind string, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h, ok := c.histograms[kind]
+	if !ok {
+		h = &histogram{buckets: make([]uint64, len(commandBuckets))}
+		c.histograms[kind] = h
+	}
+
+	seconds := d.Seconds()
+	for i, bound := range commandBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// snapshot возвращает копии гистограмм, отсортированные по типу
+func (c *CommandStats) snapshot() ([]string, map[string]histogram) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	kinds := make([]string, 0, len(c.histograms))
+	result := make(map[string]histogram, len(c.histograms))
+	for kind, h := range c.histograms {
+		kinds = append(kinds, kind)
+		result[kind] = histogram{buckets: append([]uint64(nil), h.buckets...), count: h.count, sum: h.sum}
+	}
+	sort.Strings(kinds)
+	return kinds, result
+}
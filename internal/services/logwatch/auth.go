@@ -0,0 +1,335 @@
+package logwatch
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"os/user"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tgbot/internal/services/notify"
+	"tgbot/pkg/config"
+	"tgbot/pkg/schedule"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// authLogFiles журналы авторизации для систем без systemd (Debian/Ubuntu и RHEL)
+var authLogFiles = []string{"/var/log/auth.log", "/var/log/secure"}
+
+// maxTrackedFailures количество адресов, после которого забытые счетчики неудачных входов удаляются
+const maxTrackedFailures = 1000
+
+var (
+	// 2024-10-18T12:00:00+0000 host sshd[123]: сообщение или Oct 18 12:00:00 host sshd[123]: сообщение
+	syslogLinePattern = regexp.MustCompile(`^(?:\w{3}\s+\d+\s+[\d:]+|\S+T\S+)\s+\S+\s+([\w.-]+)(?:\[(\d+)\])?:\s(.*)$`)
+	// Accepted publickey for alice from 203.0.113.5 port 52144 ssh2: ED25519 SHA256:...
+	sshAcceptedPattern = regexp.MustCompile(`^Accepted (\S+) for (\S+) from (\S+) port \d+ \w+(?:: (\S+) (\S+))?`)
+	// Failed password for invalid user admin from 203.0.113.5 port 52144 ssh2
+	sshFailedPattern = regexp.MustCompile(`^Failed \S+ for (?:invalid user )?(\S+) from (\S+) port`)
+	// Connection closed by invalid user admin 203.0.113.5 port 52144 [preauth]
+	// Disconnected from authenticating user root 203.0.113.5 port 52144 [preauth]
+	sshPreauthClosedPattern = regexp.MustCompile(`^(?:Connection closed by|Disconnected from|Disconnecting) (?:invalid|authenticating) user (\S+) (\S+) port \d+`)
+	// alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; COMMAND=/usr/bin/apt update
+	sudoCommandPattern = regexp.MustCompile(`^\s*(\S+) : .*USER=(\S+) ; COMMAND=(.*)$`)
+	// alice : 3 incorrect password attempts ; TTY=... или alice : user NOT in sudoers ; TTY=...
+	sudoFailedPattern = regexp.MustCompile(`^\s*(\S+) : (\d+ incorrect password attempts?|user NOT in sudoers|command not allowed)`)
+)
+
+// loginFailures неудачные попытки входа с одного адреса
+type loginFailures struct {
+	times []time.Time
+	users map[string]bool
+}
+
+// AuthWatcher отслеживает журнал авторизации и уведомляет о входах по SSH,
+// сериях неудачных попыток входа и использовании sudo
+type AuthWatcher struct {
+	config   config.AuthLogConfig
+	router   *notify.Router
+	tailer   *Tailer
+	limiter  *rateLimiter
+	networks []*net.IPNet
+	// botUser пользователь бота; его собственные вызовы sudo не отслеживаются
+	botUser  string
+	mu       sync.Mutex
+	failures map[string]*loginFailures
+	// failedConnections PID процессов sshd, для соединений которых уже учтены строки Failed
+	failedConnections map[string]bool
+}
+
+// NewAuthWatcher создает сервис слежения за журналом авторизации
+func NewAuthWatcher(router *notify.Router, cfg config.AuthLogConfig) *AuthWatcher {
+	w := &AuthWatcher{
+		config:   cfg,
+		router:   router,
+		limiter:  newRateLimiter(time.Duration(cfg.Cooldown) * time.Second),
+		failures: make(map[string]*loginFailures),
+
+		failedConnections: make(map[string]bool),
+	}
+
+	for _, value := range cfg.AllowedIPs {
+		network, err := parseNetwork(value)
+		if err != nil {
+			log.Printf("Logwatch: Некорректный адрес в auth_log.allowed_ips: %q", value)
+			continue
+		}
+		w.networks = append(w.networks, network)
+	}
+	if current, err := user.Current(); err == nil {
+		w.botUser = current.Username
+	}
+
+	w.tailer = NewTailer("журнал авторизации", authLogCommand, w.handleLine)
+	return w
+}
+
+// Start запускает слежение за журналом авторизации
+func (w *AuthWatcher) Start() {
+	w.tailer.Start()
+}
+
+// Stop останавливает слежение за журналом авторизации
+func (w *AuthWatcher) Stop() {
+	w.tailer.Stop()
+}
+
+// authLogCommand возвращает команду слежения за сообщениями sshd и sudo: journalctl, а без systemd - tail журнала
+func authLogCommand() *exec.Cmd {
+	if _, err := exec.LookPath("journalctl"); err == nil {
+		// OpenSSH 9.8 и новее пишет сообщения о входе от имени sshd-session
+		return exec.Command("sudo", "journalctl", "-f", "-n", "0", "-o", "short-iso",
+			"-t", "sshd", "-t", "sshd-session", "-t", "sudo")
+	}
+	for _, file := range authLogFiles {
+		if _, err := os.Stat(file); err == nil {
+			return exec.Command("sudo", "tail", "-F", "-n", "0", file)
+		}
+	}
+	return exec.Command("sudo", "tail", "-F", "-n", "0", authLogFiles[0])
+}
+
+// handleLine обрабатывает строку журнала авторизации
+func (w *AuthWatcher) handleLine(line string) {
+	match := syslogLinePattern.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+	program, pid, message := match[1], match[2], match[3]
+
+	switch program {
+	case "sshd", "sshd-session":
+		w.handleSSH(pid, message)
+	case "sudo":
+		if w.config.Sudo {
+			w.handleSudo(message)
+		}
+	}
+}
+
+// handleSSH обрабатывает сообщение sshd; pid идентифицирует соединение
+func (w *AuthWatcher) handleSSH(pid, message string) {
+	if match := sshAcceptedPattern.FindStringSubmatch(message); match != nil {
+		method, username, address := match[1], match[2], match[3]
+		if w.routine(username, address) {
+			log.Printf("Logwatch: Вход по SSH из списка разрешенных: %s с %s", username, address)
+			return
+		}
+
+		text := fmt.Sprintf("🔑 Вход по SSH: %s с %s\n", username, address)
+		switch {
+		case method == "publickey" && match[5] != "":
+			text += fmt.Sprintf("Ключ: %s %s", match[4], match[5])
+		case method == "password":
+			text += "Метод: пароль"
+		default:
+			text += "Метод: " + method
+		}
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("👥 Сеансы", "who"),
+			),
+		)
+		w.router.Send(notify.Notification{
+			Source:   notify.SourceAuth,
+			Severity: notify.SeverityCritical,
+			Text:     text,
+			Keyboard: &keyboard,
+		})
+		return
+	}
+
+	if match := sshFailedPattern.FindStringSubmatch(message); match != nil {
+		w.mu.Lock()
+		if len(w.failedConnections) >= maxTrackedFailures {
+			w.failedConnections = make(map[string]bool)
+		}
+		w.failedConnections[pid] = true
+		w.mu.Unlock()
+
+		w.handleFailure(match[1], match[2], time.Now())
+		return
+	}
+
+	// Сканеры часто закрывают соединение до попытки пароля или ключа: "Invalid user" без строки Failed
+	// Такое соединение считается одной неудачной попыткой, если его попытки еще не учтены по строкам Failed
+	if match := sshPreauthClosedPattern.FindStringSubmatch(message); match != nil {
+		w.mu.Lock()
+		counted := w.failedConnections[pid]
+		delete(w.failedConnections, pid)
+		w.mu.Unlock()
+
+		if !counted {
+			w.handleFailure(match[1], match[2], time.Now())
+		}
+	}
+}
+
+// handleFailure учитывает неудачную попытку входа и уведомляет о серии попыток с одного адреса
+func (w *AuthWatcher) handleFailure(username, address string, now time.Time) {
+	if w.allowedIP(address) {
+		return
+	}
+
+	window := time.Duration(w.config.FailedWindow) * time.Second
+
+	w.mu.Lock()
+	if len(w.failures) >= maxTrackedFailures {
+		for key, failures := range w.failures {
+			if now.Sub(failures.times[len(failures.times)-1]) > window {
+				delete(w.failures, key)
+			}
+		}
+	}
+	failures, ok := w.failures[address]
+	if !ok {
+		failures = &loginFailures{users: make(map[string]bool)}
+		w.failures[address] = failures
+	}
+	// Учитываются только попытки в пределах окна
+	recent := failures.times[:0]
+	for _, t := range failures.times {
+		if now.Sub(t) <= window {
+			recent = append(recent, t)
+		}
+	}
+	failures.times = append(recent, now)
+	if len(recent) == 0 {
+		failures.users = make(map[string]bool)
+	}
+	failures.users[username] = true
+
+	count := len(failures.times)
+	users := make([]string, 0, len(failures.users))
+	for name := range failures.users {
+		users = append(users, name)
+	}
+	w.mu.Unlock()
+
+	if count < w.config.FailedThreshold {
+		return
+	}
+	if allowed, _ := w.limiter.Allow("failed:"+address, now); !allowed {
+		return
+	}
+
+	sort.Strings(users)
+	w.router.Send(notify.Notification{
+		Source:   notify.SourceAuth,
+		Severity: notify.SeverityWarning,
+		Text: fmt.Sprintf("🚫 Неудачные попытки входа по SSH с %s: %d за %s\nПользователи: %s",
+			address, count, schedule.FormatDuration(window), strings.Join(users, ", ")),
+	})
+}
+
+// handleSudo обрабатывает сообщение sudo
+func (w *AuthWatcher) handleSudo(message string) {
+	if match := sudoFailedPattern.FindStringSubmatch(message); match != nil {
+		username := match[1]
+		if username == w.botUser {
+			return
+		}
+		w.router.Send(notify.Notification{
+			Source:   notify.SourceAuth,
+			Severity: notify.SeverityWarning,
+			Text:     fmt.Sprintf("⚠️ Отказ sudo для %s: %s", username, match[2]),
+		})
+		return
+	}
+
+	if match := sudoCommandPattern.FindStringSubmatch(message); match != nil {
+		username, target, command := match[1], match[2], strings.TrimSpace(match[3])
+		// Бот сам выполняет привилегированные команды через sudo
+		if username == w.botUser || w.allowedUser(username) {
+			return
+		}
+		if allowed, _ := w.limiter.Allow("sudo:"+username+":"+command, time.Now()); !allowed {
+			return
+		}
+		w.router.Send(notify.Notification{
+			Source:   notify.SourceAuth,
+			Severity: notify.SeverityInfo,
+			Text:     fmt.Sprintf("🛡 sudo: %s → %s\n%s", username, target, command),
+		})
+	}
+}
+
+// routine проверяет, что вход ожидаем: пользователь и адрес входят в заданные списки разрешенных
+// Незаданный список не ограничивает; если не задан ни один, уведомления отправляются обо всех входах
+func (w *AuthWatcher) routine(username, address string) bool {
+	if len(w.config.AllowedUsers) == 0 && len(w.networks) == 0 {
+		return false
+	}
+	if len(w.config.AllowedUsers) > 0 && !w.allowedUser(username) {
+		return false
+	}
+	return len(w.networks) == 0 || w.allowedIP(address)
+}
+
+// allowedUser проверяет, входит ли пользователь в список разрешенных
+func (w *AuthWatcher) allowedUser(username string) bool {
+	for _, allowed := range w.config.AllowedUsers {
+		if allowed == username {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedIP проверяет, входит ли адрес в разрешенные сети
+func (w *AuthWatcher) allowedIP(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range w.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNetwork разбирает адрес или сеть в нотации CIDR
+func parseNetwork(value string) (*net.IPNet, error) {
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		return network, err
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("некорректный адрес %q", value)
+	}
+	bits := 128
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
@@ -0,0 +1,273 @@
+package logwatch
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tgbot/internal/services/docker"
+	"tgbot/internal/services/notify"
+	"tgbot/internal/services/system"
+	"tgbot/pkg/config"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Виды событий журнала ядра
+const (
+	kernelEventOOM        = "oom"
+	kernelEventFilesystem = "filesystem"
+	kernelEventIO         = "io"
+	kernelEventSegfault   = "segfault"
+)
+
+// maxPendingOOM количество запомненных строк oom-kill, ожидающих строки о завершении процесса
+const maxPendingOOM = 100
+
+var (
+	// [12345.678901] сообщение; journalctl -o short-monotonic добавляет после метки "hostname kernel: "
+	kernelLinePattern = regexp.MustCompile(`^\[\s*(\d+\.\d+)\]\s*(.*)$`)
+	// oom-kill:constraint=CONSTRAINT_MEMCG,...,task_memcg=/system.slice/docker-<id>.scope,task=python3,pid=1234,uid=0
+	oomKillPattern = regexp.MustCompile(`oom-kill:.*task_memcg=([^,]*),task=([^,]*),pid=(\d+)`)
+	// Memory cgroup out of memory: Killed process 1234 (python3) total-vm:..., anon-rss:...kB, file-rss:...kB, shmem-rss:...kB
+	oomKilledPattern = regexp.MustCompile(`Killed process (\d+) \(([^)]*)\)`)
+	oomRSSPattern    = regexp.MustCompile(`(?:anon|file|shmem)-rss:(\d+)kB`)
+	// python3[1234]: segfault at 0 ip ... или traps: python3[1234] general protection fault ...
+	segfaultPattern = regexp.MustCompile(`(\S+)\[(\d+)\]:? (?:segfault at|general protection)`)
+	// EXT4-fs error (device sda1): ..., XFS (sdb1): Corruption detected, BTRFS error (device sda2): ...
+	filesystemPattern = regexp.MustCompile(`EXT[234]-fs error|EXT[234]-fs \([^)]*\): (?:error|Remounting filesystem read-only)|XFS \([^)]*\): (?:Corruption|metadata I/O error|Filesystem has been shut down)|BTRFS (?:error|critical)`)
+	filesystemDevice  = regexp.MustCompile(`(?:EXT[234]-fs|XFS|BTRFS)[^(]*\((?:device )?([^)]+)\)`)
+	// blk_update_request: I/O error, dev sda, sector ...; Buffer I/O error on dev sda1, logical block ...
+	ioErrorPattern  = regexp.MustCompile(`I/O error|critical medium error`)
+	ioDevicePattern = regexp.MustCompile(`dev ([\w.-]+)`)
+	// Идентификатор контейнера Docker в пути cgroup: /docker/<id> или /system.slice/docker-<id>.scope
+	containerIDPattern = regexp.MustCompile(`docker[-/]([0-9a-f]{64})`)
+)
+
+// kernelEvent событие журнала ядра, о котором отправляется уведомление
+type kernelEvent struct {
+	kind    string
+	message string
+	// key определяет однотипные события для ограничения частоты уведомлений
+	key     string
+	process string
+	pid     int
+	// rss память процесса в байтах на момент завершения OOM killer
+	rss    uint64
+	cgroup string
+	device string
+}
+
+// KernelWatcher отслеживает журнал ядра и уведомляет о завершении процессов OOM killer,
+// ошибках файловых систем, ввода-вывода и аварийных завершениях процессов
+type KernelWatcher struct {
+	router        *notify.Router
+	systemService *system.Monitor
+	dockerService *docker.Manager
+	tailer        *Tailer
+	limiter       *rateLimiter
+	mu            sync.Mutex
+	lastTimestamp float64
+	oomCgroups    map[int]string
+}
+
+// NewKernelWatcher создает сервис слежения за журналом ядра
+func NewKernelWatcher(router *notify.Router, cfg config.KernelLogConfig, systemService *system.Monitor, dockerService *docker.Manager) *KernelWatcher {
+	w := &KernelWatcher{
+		router:        router,
+		systemService: systemService,
+		dockerService: dockerService,
+		limiter:       newRateLimiter(time.Duration(cfg.Cooldown) * time.Second),
+		oomCgroups:    make(map[int]string),
+	}
+	w.tailer = NewTailer("журнал ядра", kernelLogCommand, w.handleLine)
+	return w
+}
+
+// Start запускает слежение за журналом ядра
+func (w *KernelWatcher) Start() {
+	// dmesg -w сначала выводит весь кольцевой буфер, поэтому события до запуска пропускаются
+	if uptime, _, err := w.systemService.GetUptime(); err == nil {
+		w.lastTimestamp = uptime.Seconds()
+	}
+	w.tailer.Start()
+}
+
+// Stop останавливает слежение за журналом ядра
+func (w *KernelWatcher) Stop() {
+	w.tailer.Stop()
+}
+
+// kernelLogCommand возвращает команду слежения за журналом ядра: journalctl, а без systemd - dmesg
+// Обе команды выводят монотонную метку времени, по которой отсеиваются уже обработанные строки
+func kernelLogCommand() *exec.Cmd {
+	if _, err := exec.LookPath("journalctl"); err == nil {
+		return exec.Command("sudo", "journalctl", "-k", "-f", "-n", "0", "-o", "short-monotonic")
+	}
+	return exec.Command("sudo", "dmesg", "-w")
+}
+
+// handleLine обрабатывает строку журнала ядра
+func (w *KernelWatcher) handleLine(line string) {
+	match := kernelLinePattern.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+	timestamp, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return
+	}
+	message := match[2]
+	// Формат journalctl: "hostname kernel: сообщение"
+	if idx := strings.Index(message, " kernel: "); idx != -1 && !strings.Contains(message[:idx], " ") {
+		message = message[idx+len(" kernel: "):]
+	}
+
+	w.mu.Lock()
+	// После перезапуска dmesg повторно выводит уже обработанные строки
+	if timestamp < w.lastTimestamp {
+		w.mu.Unlock()
+		return
+	}
+	w.lastTimestamp = timestamp
+	event := w.parse(message)
+	w.mu.Unlock()
+
+	if event == nil {
+		return
+	}
+	allowed, suppressed := w.limiter.Allow(event.key, time.Now())
+	if !allowed {
+		return
+	}
+	w.notify(event, suppressed)
+}
+
+// parse распознает событие в сообщении ядра; вызывается под блокировкой
+func (w *KernelWatcher) parse(message string) *kernelEvent {
+	// Строка oom-kill с cgroup процесса предшествует строке о его завершении
+	if match := oomKillPattern.FindStringSubmatch(message); match != nil {
+		if len(w.oomCgroups) >= maxPendingOOM {
+			w.oomCgroups = make(map[int]string)
+		}
+		pid, _ := strconv.Atoi(match[3])
+		w.oomCgroups[pid] = match[1]
+		return nil
+	}
+
+	if match := oomKilledPattern.FindStringSubmatch(message); match != nil {
+		pid, _ := strconv.Atoi(match[1])
+		event := &kernelEvent{kind: kernelEventOOM, message: message, process: match[2], pid: pid}
+		for _, rss := range oomRSSPattern.FindAllStringSubmatch(message, -1) {
+			kb, _ := strconv.ParseUint(rss[1], 10, 64)
+			event.rss += kb << 10
+		}
+		event.cgroup = w.oomCgroups[pid]
+		delete(w.oomCgroups, pid)
+		event.key = kernelEventOOM + ":" + event.cgroup + ":" + event.process
+		return event
+	}
+
+	if filesystemPattern.MatchString(message) {
+		event := &kernelEvent{kind: kernelEventFilesystem, message: message}
+		if match := filesystemDevice.FindStringSubmatch(message); match != nil {
+			event.device = match[1]
+		}
+		event.key = kernelEventFilesystem + ":" + event.device
+		return event
+	}
+
+	if ioErrorPattern.MatchString(message) {
+		event := &kernelEvent{kind: kernelEventIO, message: message}
+		if match := ioDevicePattern.FindStringSubmatch(message); match != nil {
+			event.device = match[1]
+		}
+		event.key = kernelEventIO + ":" + event.device
+		return event
+	}
+
+	if match := segfaultPattern.FindStringSubmatch(message); match != nil {
+		pid, _ := strconv.Atoi(match[2])
+		return &kernelEvent{
+			kind:    kernelEventSegfault,
+			message: message,
+			process: match[1],
+			pid:     pid,
+			key:     kernelEventSegfault + ":" + match[1],
+		}
+	}
+
+	return nil
+}
+
+// notify отправляет уведомление о событии журнала ядра
+func (w *KernelWatcher) notify(event *kernelEvent, suppressed int) {
+	notification := notify.Notification{
+		Source:   notify.SourceKernel,
+		Severity: notify.SeverityCritical,
+	}
+
+	var text string
+	switch event.kind {
+	case kernelEventOOM:
+		text = fmt.Sprintf("💀 OOM killer завершил процесс %s (PID %d)\n", event.process, event.pid)
+		if event.rss > 0 {
+			text += fmt.Sprintf("Память процесса: %.1f MB\n", float64(event.rss)/(1<<20))
+		}
+		if event.cgroup != "" {
+			text += fmt.Sprintf("Cgroup: %s\n", event.cgroup)
+		}
+		if container := w.container(event.cgroup); container != nil {
+			text += fmt.Sprintf("🐳 Контейнер: %s\n", container.Name)
+			keyboard := tgbotapi.NewInlineKeyboardMarkup(
+				tgbotapi.NewInlineKeyboardRow(
+					tgbotapi.NewInlineKeyboardButtonData("🐳 Контейнер", "container:"+container.ID),
+					tgbotapi.NewInlineKeyboardButtonData("📝 Логи", "logs:"+container.ID),
+				),
+			)
+			notification.Keyboard = &keyboard
+		}
+	case kernelEventFilesystem:
+		text = "🗄 Ошибка файловой системы"
+		if event.device != "" {
+			text += " на " + event.device
+		}
+		text += "\n"
+	case kernelEventIO:
+		text = "💽 Ошибка ввода-вывода"
+		if event.device != "" {
+			text += " на " + event.device
+		}
+		text += "\n"
+	case kernelEventSegfault:
+		notification.Severity = notify.SeverityWarning
+		text = fmt.Sprintf("💥 Аварийное завершение процесса %s (PID %d)\n", event.process, event.pid)
+	}
+
+	text += "\n" + event.message
+	if suppressed > 0 {
+		text += fmt.Sprintf("\n\nПохожих событий с прошлого уведомления: %d", suppressed)
+	}
+	notification.Text = text
+
+	log.Printf("Logwatch: Событие журнала ядра (%s): %s", event.kind, event.message)
+	w.router.Send(notification)
+}
+
+// container возвращает контейнер Docker, которому принадлежит cgroup, или nil
+func (w *KernelWatcher) container(cgroup string) *docker.Container {
+	match := containerIDPattern.FindStringSubmatch(cgroup)
+	if match == nil {
+		return nil
+	}
+	containers, err := w.dockerService.ListContainers(match[1])
+	if err != nil || len(containers) == 0 {
+		return nil
+	}
+	return &containers[0]
+}
@@ -0,0 +1,64 @@
+package logwatch
+
+import (
+	"sync"
+	"time"
+)
+
+// maxLimiterEntries количество ключей, после которого забытые записи удаляются
+const maxLimiterEntries = 1000
+
+// rateLimiter ограничивает частоту уведомлений об однотипных событиях
+type rateLimiter struct {
+	cooldown time.Duration
+	mu       sync.Mutex
+	entries  map[string]*limiterEntry
+}
+
+// limiterEntry последнее уведомление по ключу и число подавленных после него событий
+type limiterEntry struct {
+	last       time.Time
+	suppressed int
+}
+
+// newRateLimiter создает ограничитель с минимальным интервалом между уведомлениями по одному ключу
+func newRateLimiter(cooldown time.Duration) *rateLimiter {
+	return &rateLimiter{
+		cooldown: cooldown,
+		entries:  make(map[string]*limiterEntry),
+	}
+}
+
+// Allow сообщает, можно ли отправить уведомление по ключу
+// При разрешении возвращает число событий, подавленных с предыдущего уведомления
+func (l *rateLimiter) Allow(key string, now time.Time) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[key]
+	if ok && now.Sub(entry.last) < l.cooldown {
+		entry.suppressed++
+		return false, 0
+	}
+
+	if !ok {
+		if len(l.entries) >= maxLimiterEntries {
+			l.prune(now)
+		}
+		entry = &limiterEntry{}
+		l.entries[key] = entry
+	}
+	suppressed := entry.suppressed
+	entry.last = now
+	entry.suppressed = 0
+	return true, suppressed
+}
+
+// prune удаляет записи, интервал ограничения которых истек
+func (l *rateLimiter) prune(now time.Time) {
+	for key, entry := range l.entries {
+		if now.Sub(entry.last) >= l.cooldown {
+			delete(l.entries, key)
+		}
+	}
+}
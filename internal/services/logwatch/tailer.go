@@ -0,0 +1,129 @@
+package logwatch
+
+import (
+	"bufio"
+	"log"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Задержка перезапуска команды слежения за журналом; удваивается при повторных сбоях
+const (
+	minRestartDelay = 5 * time.Second
+	maxRestartDelay = 5 * time.Minute
+)
+
+// stableRunTime время работы команды, после которого задержка перезапуска сбрасывается
+const stableRunTime = time.Minute
+
+// Tailer запускает команду, выводящую журнал в режиме слежения, и передает строки обработчику
+// При завершении команды она перезапускается с нарастающей задержкой
+type Tailer struct {
+	name     string
+	command  func() *exec.Cmd
+	handle   func(line string)
+	stopChan chan struct{}
+	mu       sync.Mutex
+	cmd      *exec.Cmd
+	stopped  bool
+}
+
+// NewTailer создает источник строк журнала
+// command вызывается при каждом запуске, поэтому может выбирать доступную в системе команду
+func NewTailer(name string, command func() *exec.Cmd, handle func(line string)) *Tailer {
+	return &Tailer{
+		name:     name,
+		command:  command,
+		handle:   handle,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start запускает слежение за журналом
+func (t *Tailer) Start() {
+	go t.run()
+}
+
+// Stop останавливает слежение и завершает запущенную команду
+func (t *Tailer) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopped {
+		return
+	}
+	t.stopped = true
+	close(t.stopChan)
+	// sudo передает SIGTERM дочерней команде, SIGKILL завершил бы только sudo
+	if t.cmd != nil && t.cmd.Process != nil {
+		t.cmd.Process.Signal(syscall.SIGTERM)
+	}
+}
+
+// run запускает команду и перезапускает её после завершения
+func (t *Tailer) run() {
+	delay := minRestartDelay
+	for {
+		started := time.Now()
+		err := t.follow()
+
+		select {
+		case <-t.stopChan:
+			return
+		default:
+		}
+
+		if time.Since(started) > stableRunTime {
+			delay = minRestartDelay
+		}
+		log.Printf("Logwatch: %s: команда слежения завершилась (%v), перезапуск через %s", t.name, err, delay)
+
+		select {
+		case <-time.After(delay):
+		case <-t.stopChan:
+			return
+		}
+		if delay *= 2; delay > maxRestartDelay {
+			delay = maxRestartDelay
+		}
+	}
+}
+
+// follow выполняет команду и передает обработчику каждую строку её вывода
+func (t *Tailer) follow() error {
+	cmd := t.command()
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	if t.stopped {
+		t.mu.Unlock()
+		return nil
+	}
+	if err := cmd.Start(); err != nil {
+		t.mu.Unlock()
+		return err
+	}
+	t.cmd = cmd
+	t.mu.Unlock()
+
+	scanner := bufio.NewScanner(stdout)
+	// Строки журнала ядра со стеком вызовов бывают длиннее размера буфера по умолчанию
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		t.handle(scanner.Text())
+	}
+
+	err = cmd.Wait()
+	t.mu.Lock()
+	t.cmd = nil
+	t.mu.Unlock()
+	if err == nil {
+		err = scanner.Err()
+	}
+	return err
+}
@@ -0,0 +1,291 @@
+package maintenance
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"tgbot/internal/services/system"
+	"tgbot/pkg/schedule"
+	"tgbot/pkg/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Имена файлов состояния в директории данных
+const (
+	scheduleFileName = "reboot-schedule.json"
+	pendingFileName  = "reboot-pending.json"
+)
+
+// checkInterval период проверки расписания перезагрузки
+const checkInterval = 15 * time.Second
+
+// missedRebootGrace допустимое опоздание плановой перезагрузки
+// Перезагрузка, пропущенная из-за остановки бота или сервера, не выполняется спустя часы после назначенного окна
+const missedRebootGrace = 2 * checkInterval
+
+// upgradeRebootDelay задержка плановой перезагрузки, если в назначенное время выполняется обновление системы
+const upgradeRebootDelay = 5 * time.Minute
+
+// reminders моменты уведомлений перед запланированной перезагрузкой
+var reminders = []time.Duration{60 * time.Minute, 10 * time.Minute, time.Minute}
+
+// ScheduledReboot запланированная перезагрузка
+type ScheduledReboot struct {
+	At     time.Time `json:"at"`
+	ChatID int64     `json:"chat_id"`
+	// Reminded содержит уже отправленные напоминания (в минутах до перезагрузки)
+	Reminded []int `json:"reminded"`
+}
+
+// PendingReboot отметка о перезагрузке, инициированной ботом
+type PendingReboot struct {
+	ChatID      int64     `json:"chat_id"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// Scheduler сервис плановых перезагрузок сервера
+type Scheduler struct {
+	bot           *tgbotapi.BotAPI
+	systemService *system.Monitor
+	dataDir       string
+	scheduled     *ScheduledReboot
+	mu            sync.Mutex
+	stopChan      chan struct{}
+}
+
+// NewScheduler создает новый сервис плановых перезагрузок
+func NewScheduler(bot *tgbotapi.BotAPI, systemService *system.Monitor, dataDir string) *Scheduler {
+	return &Scheduler{
+		bot:           bot,
+		systemService: systemService,
+		dataDir:       dataDir,
+		stopChan:      make(chan struct{}),
+	}
+}
+
+// Start восстанавливает расписание и запускает его проверку
+func (s *Scheduler) Start() {
+	if err := s.loadState(scheduleFileName, &s.scheduled); err != nil {
+		log.Printf("Maintenance: Ошибка загрузки расписания перезагрузки: %v", err)
+	}
+
+	go s.run()
+}
+
+// Stop останавливает сервис плановых перезагрузок
+func (s *Scheduler) Stop() {
+	close(s.stopChan)
+}
+
+// Schedule планирует перезагрузку на указанное время
+func (s *Scheduler) Schedule(at time.Time, chatID int64) error {
+	if !at.After(time.Now()) {
+		return fmt.Errorf("время перезагрузки должно быть в будущем")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scheduled := &ScheduledReboot{At: at, ChatID: chatID}
+	if err := s.saveState(scheduleFileName, scheduled); err != nil {
+		return fmt.Errorf("ошибка сохранения расписания: %v", err)
+	}
+	s.scheduled = scheduled
+	return nil
+}
+
+// Cancel отменяет запланированную перезагрузку
+func (s *Scheduler) Cancel() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.scheduled == nil {
+		return false
+	}
+	s.scheduled = nil
+	s.removeState(scheduleFileName)
+	return true
+}
+
+// Scheduled возвращает запланированную перезагрузку или nil
+func (s *Scheduler) Scheduled() *ScheduledReboot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.scheduled == nil {
+		return nil
+	}
+	scheduled := *s.scheduled
+	return &scheduled
+}
+
+// RebootNow перезагружает сервер и запоминает чат для сообщения после загрузки
+func (s *Scheduler) RebootNow(chatID int64) error {
+	pending := &PendingReboot{ChatID: chatID, RequestedAt: time.Now()}
+	if err := s.saveState(pendingFileName, pending); err != nil {
+		log.Printf("Maintenance: Ошибка сохранения отметки о перезагрузке: %v", err)
+	}
+
+	if err := s.systemService.Reboot(); err != nil {
+		s.removeState(pendingFileName)
+		return err
+	}
+	return nil
+}
+
+// run периодически проверяет расписание перезагрузки
+func (s *Scheduler) run() {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.check()
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// check отправляет напоминания и выполняет перезагрузку в назначенное время
+func (s *Scheduler) check() {
+	s.mu.Lock()
+	scheduled := s.scheduled
+	if scheduled == nil {
+		s.mu.Unlock()
+		return
+	}
+
+	left := time.Until(scheduled.At)
+	if left < -missedRebootGrace {
+		s.scheduled = nil
+		s.removeState(scheduleFileName)
+		s.mu.Unlock()
+
+		log.Printf("Maintenance: Плановая перезагрузка на %s пропущена", scheduled.At.Format("2006-01-02 15:04"))
+		s.send(scheduled.ChatID, fmt.Sprintf("⚠️ Плановая перезагрузка на %s пропущена: бот или сервер не работали в назначенное время. Перезагрузка не выполнена, при необходимости запланируйте её заново.",
+			scheduled.At.Format("2006-01-02 15:04")), nil)
+		return
+	}
+	if left <= 0 && s.systemService.UpgradeRunning() {
+		// Перезагрузка прервала бы установку пакетов, поэтому откладывается до завершения обновления
+		scheduled.At = time.Now().Add(upgradeRebootDelay)
+		for _, r := range reminders {
+			if r >= upgradeRebootDelay && !containsInt(scheduled.Reminded, int(r.Minutes())) {
+				scheduled.Reminded = append(scheduled.Reminded, int(r.Minutes()))
+			}
+		}
+		if err := s.saveState(scheduleFileName, scheduled); err != nil {
+			log.Printf("Maintenance: Ошибка сохранения расписания: %v", err)
+		}
+		s.mu.Unlock()
+
+		log.Printf("Maintenance: Плановая перезагрузка отложена до %s: выполняется обновление", scheduled.At.Format("15:04"))
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("❌ Отменить перезагрузку", "reboot_cancel"),
+			),
+		)
+		s.send(scheduled.ChatID, fmt.Sprintf("⏳ Выполняется обновление системы, плановая перезагрузка отложена на %s (до %s)",
+			schedule.FormatDuration(upgradeRebootDelay), scheduled.At.Format("15:04")), &keyboard)
+		return
+	}
+	if left <= 0 {
+		s.scheduled = nil
+		s.removeState(scheduleFileName)
+		s.mu.Unlock()
+
+		s.send(scheduled.ChatID, "🔄 Выполняю запланированную перезагрузку сервера...", nil)
+		if err := s.RebootNow(scheduled.ChatID); err != nil {
+			s.send(scheduled.ChatID, fmt.Sprintf("❌ Ошибка перезагрузки сервера: %v", err), nil)
+		}
+		return
+	}
+
+	// Отправляем ближайшее неотправленное напоминание
+	var reminder time.Duration
+	for _, r := range reminders {
+		if left <= r && !containsInt(scheduled.Reminded, int(r.Minutes())) {
+			reminder = r
+		}
+	}
+	if reminder == 0 {
+		s.mu.Unlock()
+		return
+	}
+	// Более ранние напоминания считаем отправленными, чтобы не присылать их после пропуска
+	for _, r := range reminders {
+		if r >= reminder && !containsInt(scheduled.Reminded, int(r.Minutes())) {
+			scheduled.Reminded = append(scheduled.Reminded, int(r.Minutes()))
+		}
+	}
+	if err := s.saveState(scheduleFileName, scheduled); err != nil {
+		log.Printf("Maintenance: Ошибка сохранения расписания: %v", err)
+	}
+	s.mu.Unlock()
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отменить перезагрузку", "reboot_cancel"),
+		),
+	)
+	message := fmt.Sprintf("⏰ Перезагрузка сервера через %s (в %s)",
+		schedule.FormatDuration(left.Round(time.Minute)), scheduled.At.Format("15:04"))
+	s.send(scheduled.ChatID, message, &keyboard)
+}
+
+// TakePendingReboot возвращает и удаляет отметку о перезагрузке, инициированной ботом
+func (s *Scheduler) TakePendingReboot() *PendingReboot {
+	var pending *PendingReboot
+	if err := s.loadState(pendingFileName, &pending); err != nil {
+		log.Printf("Maintenance: Ошибка загрузки отметки о перезагрузке: %v", err)
+		return nil
+	}
+	if pending != nil {
+		s.removeState(pendingFileName)
+	}
+	return pending
+}
+
+// send отправляет сообщение в чат
+func (s *Scheduler) send(chatID int64, message string, keyboard *tgbotapi.InlineKeyboardMarkup) {
+	msg := tgbotapi.NewMessage(chatID, message)
+	if keyboard != nil {
+		msg.ReplyMarkup = keyboard
+	}
+	if _, err := s.bot.Send(msg); err != nil {
+		log.Printf("Maintenance: Ошибка отправки сообщения: %v", err)
+	}
+}
+
+// loadState загружает состояние из файла в директории данных
+func (s *Scheduler) loadState(name string, v interface{}) error {
+	return storage.LoadJSON(filepath.Join(s.dataDir, name), v)
+}
+
+// saveState сохраняет состояние в файл в директории данных
+func (s *Scheduler) saveState(name string, v interface{}) error {
+	return storage.SaveJSON(filepath.Join(s.dataDir, name), v)
+}
+
+// removeState удаляет файл состояния
+func (s *Scheduler) removeState(name string) {
+	if err := storage.Remove(filepath.Join(s.dataDir, name)); err != nil {
+		log.Printf("Maintenance: Ошибка удаления %s: %v", name, err)
+	}
+}
+
+// containsInt проверяет наличие значения в срезе
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"log"
+	"time"
+
+	"tgbot/internal/services/system"
+)
+
+// Имена рядов; для дисков и сетевых интерфейсов к имени добавляется ":<точка монтирования или интерфейс>"
+const (
+	SeriesCPU    = "cpu"
+	SeriesMemory = "memory"
+	SeriesSwap   = "swap"
+	SeriesLoad1  = "load1"
+	SeriesLoad5  = "load5"
+	SeriesLoad15 = "load15"
+	SeriesDisk   = "disk:"
+	SeriesNetRx  = "net_rx:"
+	SeriesNetTx  = "net_tx:"
+)
+
+// Collector периодически сохраняет системные метрики в хранилище
+type Collector struct {
+	store         *Store
+	systemService *system.Monitor
+	interval      time.Duration
+	// lastNet предыдущие счетчики трафика для расчета скорости
+	lastNet     map[string]system.NetCounters
+	lastNetTime time.Time
+	stopChan    chan struct{}
+}
+
+// NewCollector создает сборщик метрик
+func NewCollector(store *Store, systemService *system.Monitor, interval time.Duration) *Collector {
+	return &Collector{
+		store:         store,
+		systemService: systemService,
+		interval:      interval,
+		lastNet:       make(map[string]system.NetCounters),
+		stopChan:      make(chan struct{}),
+	}
+}
+
+// Start запускает сбор метрик
+func (c *Collector) Start() {
+	go c.run()
+}
+
+// Stop останавливает сбор метрик и закрывает хранилище
+func (c *Collector) Stop() {
+	close(c.stopChan)
+	if err := c.store.Close(); err != nil {
+		log.Printf("Metrics: Ошибка закрытия хранилища: %v", err)
+	}
+}
+
+// Store возвращает хранилище метрик для запросов истории
+func (c *Collector) Store() *Store {
+	return c.store
+}
+
+// run выполняет периодический сбор метрик
+func (c *Collector) run() {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.collect(time.Now())
+		case <-c.stopChan:
+			return
+		}
+	}
+}
+
+// collect получает текущие значения метрик и записывает их в хранилище
+func (c *Collector) collect(now time.Time) {
+	if cpuInfo, err := c.systemService.GetCPUInfo(); err == nil {
+		c.append(SeriesCPU, now, cpuInfo.Load)
+	}
+
+	if memInfo, err := c.systemService.GetMemoryInfo(); err == nil {
+		c.append(SeriesMemory, now, memInfo.UsedPercent)
+		c.append(SeriesSwap, now, memInfo.SwapPercent)
+	}
+
+	if loadInfo, err := c.systemService.GetLoadAverage(); err == nil {
+		c.append(SeriesLoad1, now, loadInfo.Load1)
+		c.append(SeriesLoad5, now, loadInfo.Load5)
+		c.append(SeriesLoad15, now, loadInfo.Load15)
+	}
+
+	if diskInfos, err := c.systemService.GetDiskInfo(); err == nil {
+		for _, diskInfo := range diskInfos {
+			c.append(SeriesDisk+diskInfo.MountPoint, now, diskInfo.UsedPercent)
+		}
+	}
+
+	if counters, err := c.systemService.GetNetCounters(); err == nil {
+		// Docker создает veth на каждый запуск контейнера, поэтому записываются только физические интерфейсы,
+		// а при их отсутствии (контейнер, OpenVZ) - все
+		physical := false
+		for _, counter := range counters {
+			physical = physical || counter.Physical
+		}
+
+		elapsed := now.Sub(c.lastNetTime).Seconds()
+		current := make(map[string]system.NetCounters, len(counters))
+		for _, counter := range counters {
+			if physical && !counter.Physical {
+				continue
+			}
+			current[counter.Name] = counter
+
+			last, ok := c.lastNet[counter.Name]
+			// Счетчики сбрасываются при перезапуске интерфейса, такой интервал пропускаем
+			if ok && elapsed > 0 && counter.BytesRecv >= last.BytesRecv && counter.BytesSent >= last.BytesSent {
+				c.append(SeriesNetRx+counter.Name, now, float64(counter.BytesRecv-last.BytesRecv)/elapsed)
+				c.append(SeriesNetTx+counter.Name, now, float64(counter.BytesSent-last.BytesSent)/elapsed)
+			}
+		}
+		// Счетчики исчезнувших интерфейсов не сохраняются
+		c.lastNet = current
+		c.lastNetTime = now
+	}
+}
+
+// append записывает значение в хранилище
+func (c *Collector) append(series string, at time.Time, value float64) {
+	if err := c.store.Append(series, at, value); err != nil {
+		log.Printf("Metrics: Ошибка записи метрики %s: %v", series, err)
+	}
+}
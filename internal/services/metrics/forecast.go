@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"time"
+)
+
+// Параметры прогноза заполнения
+const (
+	// ForecastWindow период истории, по которому строится тренд
+	ForecastWindow = 48 * time.Hour
+	// minForecastPoints минимальное количество точек для прогноза
+	minForecastPoints = 10
+	// minForecastSpan минимальная длительность истории для прогноза
+	minForecastSpan = time.Hour
+)
+
+// Forecast прогноз заполнения ряда, измеряемого в процентах
+type Forecast struct {
+	// Current текущее значение
+	Current float64
+	// Rate скорость роста в процентах в сутки
+	Rate float64
+	// TimeToFull время до достижения 100%; 0, если значение не растет
+	TimeToFull time.Duration
+}
+
+// Growing проверяет, ожидается ли заполнение при текущем тренде
+func (f *Forecast) Growing() bool {
+	return f.TimeToFull > 0
+}
+
+// ForecastFull строит прогноз заполнения ряда по линейному тренду за последние window
+// Возвращает nil, если истории недостаточно для прогноза
+func (s *Store) ForecastFull(series string, window time.Duration, now time.Time) (*Forecast, error) {
+	points, err := s.Query(series, now.Add(-window), now)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) < minForecastPoints || points[len(points)-1].Time.Sub(points[0].Time) < minForecastSpan {
+		return nil, nil
+	}
+
+	slope := trend(points)
+	forecast := &Forecast{
+		Current: points[len(points)-1].Value,
+		Rate:    slope * (24 * time.Hour).Seconds(),
+	}
+	if slope > 0 && forecast.Current < 100 {
+		forecast.TimeToFull = time.Duration((100 - forecast.Current) / slope * float64(time.Second))
+	}
+	return forecast, nil
+}
+
+// trend возвращает наклон линейной регрессии значений по времени (в единицах в секунду)
+func trend(points []Point) float64 {
+	// Время отсчитывается от первой точки, чтобы не терять точность на больших значениях Unix-времени
+	origin := points[0].Time
+	var sumX, sumY, sumXY, sumXX float64
+	for _, point := range points {
+		x := point.Time.Sub(origin).Seconds()
+		sumX += x
+		sumY += point.Value
+		sumXY += x * point.Value
+		sumXX += x * x
+	}
+
+	n := float64(len(points))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
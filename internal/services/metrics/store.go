@@ -0,0 +1,299 @@
+package metrics
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Размеры заголовка файла ряда и одной ячейки кольцевого буфера
+const (
+	headerSize = 16
+	slotSize   = 16
+)
+
+// fileIdleTimeout время без обращений, после которого файл ряда закрывается
+// Ряды исчезнувших дисков и интерфейсов иначе удерживали бы дескрипторы до остановки бота
+const fileIdleTimeout = time.Hour
+
+// Point значение метрики в момент времени
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Tier уровень хранения: шаг между точками и глубина хранения
+type Tier struct {
+	Name      string
+	Step      time.Duration
+	Retention time.Duration
+}
+
+// slots возвращает количество ячеек кольцевого буфера уровня
+func (t Tier) slots() int64 {
+	return int64(t.Retention / t.Step)
+}
+
+// bucket возвращает номер интервала уровня, в который попадает время
+func (t Tier) bucket(at time.Time) int64 {
+	return at.Unix() / int64(t.Step/time.Second)
+}
+
+// Store хранилище временных рядов в кольцевых буферах на диске
+// Каждый ряд каждого уровня хранится в отдельном файле фиксированного размера,
+// ячейка для точки определяется её временем, поэтому старые значения перезаписываются по кругу
+type Store struct {
+	dir   string
+	tiers []Tier
+	files map[string]*os.File
+	// used время последнего обращения к открытым файлам
+	used map[string]time.Time
+	// rollups накапливают значения для усреднения в более грубые уровни
+	rollups map[string]*rollup
+	mu      sync.Mutex
+}
+
+// rollup накопитель значений одного ряда за текущий интервал грубого уровня
+type rollup struct {
+	bucket int64
+	sum    float64
+	count  int
+}
+
+// NewStore открывает хранилище в директории; первый уровень принимает исходные значения,
+// последующие получают средние значения за свой шаг
+func NewStore(dir string, tiers []Tier) (*Store, error) {
+	if len(tiers) == 0 {
+		return nil, fmt.Errorf("не задан ни один уровень хранения")
+	}
+	for _, tier := range tiers {
+		if tier.Step < time.Second || tier.slots() <= 0 {
+			return nil, fmt.Errorf("некорректный уровень хранения %s", tier.Name)
+		}
+		if err := os.MkdirAll(filepath.Join(dir, tier.Name), 0750); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Store{
+		dir:     dir,
+		tiers:   tiers,
+		files:   make(map[string]*os.File),
+		used:    make(map[string]time.Time),
+		rollups: make(map[string]*rollup),
+	}, nil
+}
+
+// Close закрывает файлы хранилища
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+	for key, f := range s.files {
+		if err := f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(s.files, key)
+		delete(s.used, key)
+	}
+	return firstErr
+}
+
+// Append записывает значение ряда в момент времени
+func (s *Store) Append(series string, at time.Time, value float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.write(s.tiers[0], series, at, value); err != nil {
+		return err
+	}
+
+	for _, tier := range s.tiers[1:] {
+		key := tier.Name + "/" + series
+		bucket := tier.bucket(at)
+
+		acc, ok := s.rollups[key]
+		if ok && acc.bucket != bucket && acc.count > 0 {
+			// Интервал завершен, записываем среднее значение с временем начала интервала
+			start := time.Unix(acc.bucket*int64(tier.Step/time.Second), 0)
+			if err := s.write(tier, series, start, acc.sum/float64(acc.count)); err != nil {
+				return err
+			}
+		}
+		if !ok || acc.bucket != bucket {
+			acc = &rollup{bucket: bucket}
+			s.rollups[key] = acc
+		}
+		acc.sum += value
+		acc.count++
+	}
+	return nil
+}
+
+// Query возвращает точки ряда за период из самого подробного уровня, покрывающего его начало
+func (s *Store) Query(series string, from, to time.Time) ([]Point, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tier := s.tierFor(from)
+	f, err := s.file(tier, series, false)
+	if err != nil || f == nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.NewSectionReader(f, headerSize, tier.slots()*slotSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var points []Point
+	for offset := 0; offset+slotSize <= len(data); offset += slotSize {
+		ts := int64(binary.LittleEndian.Uint64(data[offset:]))
+		if ts == 0 {
+			continue
+		}
+		at := time.Unix(ts, 0)
+		if at.Before(from) || at.After(to) {
+			continue
+		}
+		value := math.Float64frombits(binary.LittleEndian.Uint64(data[offset+8:]))
+		points = append(points, Point{Time: at, Value: value})
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Time.Before(points[j].Time)
+	})
+	return points, nil
+}
+
+// Series возвращает имена записанных рядов с указанным префиксом
+func (s *Store) Series(prefix string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, s.tiers[0].Name))
+	if err != nil {
+		return nil, err
+	}
+
+	var series []string
+	for _, entry := range entries {
+		name, err := url.PathUnescape(strings.TrimSuffix(entry.Name(), ".ring"))
+		if err != nil || !strings.HasSuffix(entry.Name(), ".ring") {
+			continue
+		}
+		if strings.HasPrefix(name, prefix) {
+			series = append(series, name)
+		}
+	}
+	sort.Strings(series)
+	return series, nil
+}
+
+// Tiers возвращает уровни хранения
+func (s *Store) Tiers() []Tier {
+	return s.tiers
+}
+
+// tierFor выбирает самый подробный уровень, глубина которого покрывает момент from
+func (s *Store) tierFor(from time.Time) Tier {
+	age := time.Since(from)
+	for _, tier := range s.tiers {
+		if age <= tier.Retention {
+			return tier
+		}
+	}
+	return s.tiers[len(s.tiers)-1]
+}
+
+// write записывает точку в ячейку кольцевого буфера уровня
+// Вызывается с захваченной блокировкой
+func (s *Store) write(tier Tier, series string, at time.Time, value float64) error {
+	f, err := s.file(tier, series, true)
+	if err != nil {
+		return err
+	}
+
+	slot := tier.bucket(at) % tier.slots()
+	buf := make([]byte, slotSize)
+	binary.LittleEndian.PutUint64(buf, uint64(at.Unix()))
+	binary.LittleEndian.PutUint64(buf[8:], math.Float64bits(value))
+
+	_, err = f.WriteAt(buf, headerSize+slot*slotSize)
+	return err
+}
+
+// file возвращает открытый файл ряда уровня
+// Если параметры уровня изменились с момента создания файла, файл пересоздается
+// Вызывается с захваченной блокировкой
+func (s *Store) file(tier Tier, series string, create bool) (*os.File, error) {
+	key := tier.Name + "/" + series
+	now := time.Now()
+	if f, ok := s.files[key]; ok {
+		s.used[key] = now
+		return f, nil
+	}
+	s.closeIdle(now)
+
+	path := filepath.Join(s.dir, tier.Name, url.PathEscape(series)+".ring")
+	flags := os.O_RDWR
+	if create {
+		flags |= os.O_CREATE
+	}
+	f, err := os.OpenFile(path, flags, 0640)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, headerSize)
+	step := uint64(tier.Step / time.Second)
+	slots := uint64(tier.slots())
+	if _, err := f.ReadAt(header, 0); err != nil ||
+		binary.LittleEndian.Uint64(header) != step || binary.LittleEndian.Uint64(header[8:]) != slots {
+		if err := initRing(f, step, slots); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("ошибка создания файла %s: %v", path, err)
+		}
+	}
+
+	s.files[key] = f
+	s.used[key] = now
+	return f, nil
+}
+
+// closeIdle закрывает файлы, к которым не было обращений дольше fileIdleTimeout
+// Вызывается с захваченной блокировкой перед открытием нового файла
+func (s *Store) closeIdle(now time.Time) {
+	for key, used := range s.used {
+		if now.Sub(used) <= fileIdleTimeout {
+			continue
+		}
+		s.files[key].Close()
+		delete(s.files, key)
+		delete(s.used, key)
+	}
+}
+
+// initRing очищает файл и записывает заголовок с параметрами уровня
+func initRing(f *os.File, step, slots uint64) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+
+	header := make([]byte, headerSize)
+	binary.LittleEndian.PutUint64(header, step)
+	binary.LittleEndian.PutUint64(header[8:], slots)
+	if _, err := f.WriteAt(header, 0); err != nil {
+		return err
+	}
+	return f.Truncate(headerSize + int64(slots)*slotSize)
+}
@@ -0,0 +1,195 @@
+package monitoring
+
+import (
+	"time"
+
+	"tgbot/internal/services/notify"
+)
+
+// AlertState состояние алерта
+type AlertState int
+
+const (
+	// AlertOK метрика в норме
+	AlertOK AlertState = iota
+	// AlertPending порог превышен, но еще не выдержана требуемая длительность
+	AlertPending
+	// AlertFiring алерт активен, уведомление отправлено
+	AlertFiring
+)
+
+// String возвращает название состояния алерта
+func (s AlertState) String() string {
+	switch s {
+	case AlertPending:
+		return "pending"
+	case AlertFiring:
+		return "firing"
+	default:
+		return "ok"
+	}
+}
+
+// AlertEvent событие, требующее отправки уведомления
+type AlertEvent int
+
+const (
+	// EventNone уведомление не требуется
+	EventNone AlertEvent = iota
+	// EventFiring алерт сработал
+	EventFiring
+	// EventEscalated уровень важности активного алерта повысился
+	EventEscalated
+	// EventReminder алерт всё еще активен, повторное напоминание
+	EventReminder
+	// EventResolved метрика вернулась в норму
+	EventResolved
+)
+
+// severityRank возвращает порядок важности уровня
+func severityRank(severity string) int {
+	switch severity {
+	case notify.SeverityCritical:
+		return 3
+	case notify.SeverityWarning:
+		return 2
+	case notify.SeverityInfo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// alertLevel порог срабатывания с уровнем важности
+type alertLevel struct {
+	severity  string
+	threshold float64
+}
+
+// alertRule правило срабатывания алерта по метрике
+type alertRule struct {
+	// operator ">" срабатывает при превышении порога, "<" при значении ниже порога
+	operator string
+	// levels пороги срабатывания, от более важного к менее важному
+	levels []alertLevel
+	// clear порог возврата в норму; отличается от порогов срабатывания для гистерезиса
+	clear float64
+	// duration время, в течение которого порог должен быть превышен
+	duration time.Duration
+	// repeat интервал повторных напоминаний, 0 отключает напоминания
+	repeat time.Duration
+}
+
+// severity возвращает уровень важности для значения или пустую строку, если пороги не нарушены
+func (r alertRule) severity(value float64) string {
+	for _, level := range r.levels {
+		if r.operator == "<" && value < level.threshold || r.operator != "<" && value > level.threshold {
+			return level.severity
+		}
+	}
+	return ""
+}
+
+// threshold возвращает порог срабатывания для уровня важности
+func (r alertRule) threshold(severity string) float64 {
+	for _, level := range r.levels {
+		if level.severity == severity {
+			return level.threshold
+		}
+	}
+	return 0
+}
+
+// cleared проверяет, вернулось ли значение за порог возврата в норму
+func (r alertRule) cleared(value float64) bool {
+	if r.operator == "<" {
+		return value >= r.clear
+	}
+	return value <= r.clear
+}
+
+// Alert состояние алерта по одной метрике
+type Alert struct {
+	Key          string
+	State        AlertState
+	Severity     string
+	Value        float64
+	PendingSince time.Time
+	FiringSince  time.Time
+	LastNotified time.Time
+	// AcknowledgedBy пользователь, подтвердивший активный алерт
+	AcknowledgedBy string
+	// Escalated алерт был эскалирован во второй канал
+	Escalated bool
+}
+
+// Evaluate обновляет состояние алерта по новому значению метрики и возвращает событие для уведомления
+func (a *Alert) Evaluate(rule alertRule, value float64, now time.Time) AlertEvent {
+	a.Value = value
+	severity := rule.severity(value)
+
+	switch a.State {
+	case AlertOK:
+		if severity == "" {
+			return EventNone
+		}
+		a.State = AlertPending
+		a.PendingSince = now
+		if rule.duration > 0 {
+			return EventNone
+		}
+		return a.fire(severity, now)
+
+	case AlertPending:
+		if severity == "" {
+			a.State = AlertOK
+			return EventNone
+		}
+		if now.Sub(a.PendingSince) >= rule.duration {
+			return a.fire(severity, now)
+		}
+		return EventNone
+
+	case AlertFiring:
+		if rule.cleared(value) {
+			a.State = AlertOK
+			a.LastNotified = now
+			return EventResolved
+		}
+		// Уровень важности не понижается до возврата в норму, чтобы колебания не вызывали повторных уведомлений
+		if severityRank(severity) > severityRank(a.Severity) {
+			a.Severity = severity
+			a.LastNotified = now
+			return EventEscalated
+		}
+		if rule.repeat > 0 && now.Sub(a.LastNotified) >= rule.repeat {
+			a.LastNotified = now
+			return EventReminder
+		}
+	}
+
+	return EventNone
+}
+
+// Vanish сбрасывает алерт, экземпляр метрики которого исчез (удален контейнер, отмонтирован диск)
+// Активный алерт завершается, иначе значение за порогом никогда бы не вернулось в норму
+func (a *Alert) Vanish(now time.Time) AlertEvent {
+	state := a.State
+	a.State = AlertOK
+	if state != AlertFiring {
+		return EventNone
+	}
+	a.LastNotified = now
+	return EventResolved
+}
+
+// fire переводит алерт в активное состояние
+func (a *Alert) fire(severity string, now time.Time) AlertEvent {
+	a.State = AlertFiring
+	a.Severity = severity
+	a.AcknowledgedBy = ""
+	a.Escalated = false
+	a.FiringSince = now
+	a.LastNotified = now
+	return EventFiring
+}
@@ -0,0 +1,102 @@
+package monitoring
+
+import (
+	"log"
+	"path/filepath"
+	"time"
+
+	"tgbot/pkg/storage"
+)
+
+// historyFileName файл истории инцидентов в директории данных
+const historyFileName = "alert-history.json"
+
+// historyRetention глубина хранения истории инцидентов
+const historyRetention = 30 * 24 * time.Hour
+
+// Incident запись об инциденте для отчетов
+type Incident struct {
+	Key        string    `json:"key"`
+	Severity   string    `json:"severity"`
+	FiredAt    time.Time `json:"fired_at"`
+	ResolvedAt time.Time `json:"resolved_at,omitempty"`
+}
+
+// Resolved проверяет, завершен ли инцидент
+func (i Incident) Resolved() bool {
+	return !i.ResolvedAt.IsZero()
+}
+
+// Incidents возвращает инциденты, активные в период с since
+func (s *Service) Incidents(since time.Time) []Incident {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var incidents []Incident
+	for _, incident := range s.history {
+		if !incident.Resolved() || incident.ResolvedAt.After(since) {
+			incidents = append(incidents, incident)
+		}
+	}
+	return incidents
+}
+
+// recordIncident обновляет историю инцидентов по событию алерта
+// Вызывается с захваченной блокировкой
+func (s *Service) recordIncident(alert *Alert, event AlertEvent, now time.Time) {
+	switch event {
+	case EventFiring:
+		s.history = append(s.history, Incident{Key: alert.Key, Severity: alert.Severity, FiredAt: now})
+	case EventEscalated, EventResolved:
+		// Обновляем последний открытый инцидент алерта
+		for i := len(s.history) - 1; i >= 0; i-- {
+			if s.history[i].Key != alert.Key || s.history[i].Resolved() {
+				continue
+			}
+			s.history[i].Severity = alert.Severity
+			if event == EventResolved {
+				s.history[i].ResolvedAt = now
+			}
+			break
+		}
+	default:
+		return
+	}
+
+	// Удаляем завершенные инциденты старше срока хранения
+	kept := s.history[:0]
+	for _, incident := range s.history {
+		if !incident.Resolved() || now.Sub(incident.ResolvedAt) < historyRetention {
+			kept = append(kept, incident)
+		}
+	}
+	s.history = kept
+
+	if err := storage.SaveJSON(s.historyPath(), s.history); err != nil {
+		log.Printf("Monitoring: Ошибка сохранения истории инцидентов: %v", err)
+	}
+}
+
+// loadHistory загружает историю инцидентов
+// Инциденты, открытые до перезапуска бота, закрываются: состояние алертов после запуска строится заново
+func (s *Service) loadHistory() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := storage.LoadJSON(s.historyPath(), &s.history); err != nil {
+		log.Printf("Monitoring: Ошибка загрузки истории инцидентов: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for i := range s.history {
+		if !s.history[i].Resolved() {
+			s.history[i].ResolvedAt = now
+		}
+	}
+}
+
+// historyPath возвращает путь к файлу истории инцидентов
+func (s *Service) historyPath() string {
+	return filepath.Join(s.config.Storage.DataDir, historyFileName)
+}
@@ -0,0 +1,504 @@
+package monitoring
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"path"
+	"sort"
+	"time"
+
+	"tgbot/internal/services/metrics"
+	"tgbot/internal/services/notify"
+	"tgbot/internal/services/system"
+	"tgbot/pkg/config"
+	"tgbot/pkg/schedule"
+)
+
+// metricSample значение метрики для одного экземпляра (точки монтирования, интерфейса)
+type metricSample struct {
+	instance string
+	value    float64
+	// unknown означает, что экземпляр существует, но значение не получено; состояние алерта не меняется
+	unknown bool
+}
+
+// metricSource описание метрики, доступной в правилах алертов
+type metricSource struct {
+	// title название метрики в уведомлениях
+	title string
+	// source источник уведомлений для маршрутизации
+	source string
+	// unit единица измерения значения
+	unit string
+	// format форматирует значение для уведомлений вместо единицы измерения
+	format func(value float64) string
+	// hysteresis разница между порогом срабатывания и возврата в норму по умолчанию
+	hysteresis float64
+	// collect получает текущие значения метрики
+	collect func(s *Service) ([]metricSample, error)
+	// details возвращает дополнительные сведения для уведомления о срабатывании
+	details func(s *Service) string
+}
+
+// metricSources метрики, доступные в правилах алертов
+var metricSources = map[string]metricSource{
+	"cpu": {
+		title:      "Нагрузка на CPU",
+		source:     notify.SourceSystem,
+		unit:       "%",
+		hysteresis: defaultHysteresis,
+		collect: func(s *Service) ([]metricSample, error) {
+			cpuInfo, err := s.systemService.GetCPUInfo()
+			if err != nil {
+				return nil, err
+			}
+			return []metricSample{{value: cpuInfo.Load}}, nil
+		},
+		details: func(s *Service) string {
+			return topProcesses(s, func(top *system.TopProcesses) []*system.ProcessInfo { return top.ByCPU })
+		},
+	},
+	"memory": {
+		title:      "Использование памяти",
+		source:     notify.SourceSystem,
+		unit:       "%",
+		hysteresis: defaultHysteresis,
+		collect: func(s *Service) ([]metricSample, error) {
+			memInfo, err := s.systemService.GetMemoryInfo()
+			if err != nil {
+				return nil, err
+			}
+			return []metricSample{{value: memInfo.UsedPercent}}, nil
+		},
+		details: func(s *Service) string {
+			return topProcesses(s, func(top *system.TopProcesses) []*system.ProcessInfo { return top.ByMemory })
+		},
+	},
+	"swap": {
+		title:      "Использование swap",
+		source:     notify.SourceSystem,
+		unit:       "%",
+		hysteresis: defaultHysteresis,
+		collect: func(s *Service) ([]metricSample, error) {
+			memInfo, err := s.systemService.GetMemoryInfo()
+			if err != nil {
+				return nil, err
+			}
+			// Без swap метрика не имеет смысла
+			if memInfo.SwapTotal == 0 {
+				return nil, nil
+			}
+			return []metricSample{{value: memInfo.SwapPercent}}, nil
+		},
+	},
+	"disk_used": {
+		title:      "Занято на диске",
+		source:     notify.SourceSystem,
+		unit:       "%",
+		hysteresis: defaultHysteresis,
+		collect: func(s *Service) ([]metricSample, error) {
+			return collectDisks(s.systemService, func(d *system.DiskInfo) float64 { return d.UsedPercent })
+		},
+	},
+	"disk_free": {
+		title:      "Свободно на диске",
+		source:     notify.SourceSystem,
+		unit:       "%",
+		hysteresis: defaultHysteresis,
+		collect: func(s *Service) ([]metricSample, error) {
+			return collectDisks(s.systemService, func(d *system.DiskInfo) float64 { return 100 - d.UsedPercent })
+		},
+	},
+	"inode_used": {
+		title:      "Использование inode",
+		source:     notify.SourceSystem,
+		unit:       "%",
+		hysteresis: defaultHysteresis,
+		collect: func(s *Service) ([]metricSample, error) {
+			diskInfos, err := s.systemService.GetDiskInfo()
+			if err != nil {
+				return nil, err
+			}
+
+			// Файловые системы без ограничения количества inode не проверяются
+			samples := make([]metricSample, 0, len(diskInfos))
+			for _, diskInfo := range diskInfos {
+				if diskInfo.InodesTotal == 0 {
+					continue
+				}
+				samples = append(samples, metricSample{instance: diskInfo.MountPoint, value: diskInfo.InodesUsedPercent})
+			}
+			return samples, nil
+		},
+	},
+	"temperature": {
+		title:      "Температура",
+		source:     notify.SourceSystem,
+		unit:       "°C",
+		hysteresis: defaultHysteresis,
+		collect: func(s *Service) ([]metricSample, error) {
+			// Без датчиков (например, на VPS) правило не срабатывает
+			sensors, err := s.systemService.GetSensors()
+			if err != nil {
+				return nil, err
+			}
+			samples := make([]metricSample, 0, len(sensors.Temperatures))
+			for _, sensor := range sensors.Temperatures {
+				samples = append(samples, metricSample{instance: sensor.Name, value: sensor.Current})
+			}
+			return samples, nil
+		},
+	},
+	"fan_rpm": {
+		title:  "Скорость вентилятора",
+		source: notify.SourceSystem,
+		format: func(rpm float64) string {
+			return fmt.Sprintf("%.0f RPM", rpm)
+		},
+		hysteresis: 100,
+		collect: func(s *Service) ([]metricSample, error) {
+			sensors, err := s.systemService.GetSensors()
+			if err != nil {
+				return nil, err
+			}
+			samples := make([]metricSample, 0, len(sensors.Fans))
+			for _, fan := range sensors.Fans {
+				samples = append(samples, metricSample{instance: fan.Name, value: float64(fan.RPM)})
+			}
+			return samples, nil
+		},
+	},
+	"disk_full": {
+		title:  "Прогноз заполнения диска",
+		source: notify.SourceSystem,
+		format: func(hours float64) string {
+			if hours >= forecastHorizonHours {
+				return "не ожидается"
+			}
+			return "через ~" + schedule.FormatDuration(time.Duration(hours*float64(time.Hour)))
+		},
+		collect: func(s *Service) ([]metricSample, error) {
+			if s.metricsStore == nil {
+				return nil, fmt.Errorf("история метрик отключена")
+			}
+			diskInfos, err := s.systemService.GetDiskInfo()
+			if err != nil {
+				return nil, err
+			}
+
+			now := time.Now()
+			samples := make([]metricSample, 0, len(diskInfos))
+			for _, diskInfo := range diskInfos {
+				forecast, err := s.metricsStore.ForecastFull(metrics.SeriesDisk+diskInfo.MountPoint, metrics.ForecastWindow, now)
+				// Без достаточной истории прогноз не строится, состояние алерта не меняется
+				if err != nil || forecast == nil {
+					samples = append(samples, metricSample{instance: diskInfo.MountPoint, unknown: true})
+					continue
+				}
+				hours := float64(forecastHorizonHours)
+				if forecast.Growing() {
+					hours = math.Min(forecast.TimeToFull.Hours(), forecastHorizonHours)
+				}
+				samples = append(samples, metricSample{instance: diskInfo.MountPoint, value: hours})
+			}
+			return samples, nil
+		},
+	},
+	"traffic_used": {
+		title:      "Расход квоты трафика",
+		source:     notify.SourceSystem,
+		unit:       "%",
+		hysteresis: defaultHysteresis,
+		collect: func(s *Service) ([]metricSample, error) {
+			report := s.trafficAccountant.Report(time.Now())
+			if report.Quota == 0 {
+				return nil, fmt.Errorf("квота трафика не задана (параметр traffic.quota)")
+			}
+			return []metricSample{{value: report.UsedPercent()}}, nil
+		},
+	},
+	"traffic_projected": {
+		title:      "Прогноз расхода квоты трафика к концу периода",
+		source:     notify.SourceSystem,
+		unit:       "%",
+		hysteresis: defaultHysteresis,
+		collect: func(s *Service) ([]metricSample, error) {
+			report := s.trafficAccountant.Report(time.Now())
+			if report.Quota == 0 {
+				return nil, fmt.Errorf("квота трафика не задана (параметр traffic.quota)")
+			}
+			// Без достаточной истории прогноз не строится, состояние алерта не меняется
+			if report.Projected == 0 {
+				return nil, nil
+			}
+			return []metricSample{{value: report.ProjectedPercent()}}, nil
+		},
+	},
+	"failed_services": {
+		title:  "Сервисы systemd с ошибкой",
+		source: notify.SourceSystemd,
+		collect: func(s *Service) ([]metricSample, error) {
+			failed, err := s.systemService.GetFailedServices()
+			if err != nil {
+				return nil, err
+			}
+			return []metricSample{{value: float64(len(failed))}}, nil
+		},
+	},
+	"container_down": {
+		title:  "Контейнер остановлен",
+		source: notify.SourceDocker,
+		collect: func(s *Service) ([]metricSample, error) {
+			containers, err := s.dockerService.ListContainers()
+			if err != nil {
+				return nil, err
+			}
+			// Значение 1 для остановленного контейнера, 0 для работающего
+			samples := make([]metricSample, 0, len(containers))
+			for _, container := range containers {
+				value := 0.0
+				if !container.Running() {
+					value = 1
+				}
+				samples = append(samples, metricSample{instance: container.Name, value: value})
+			}
+			return samples, nil
+		},
+	},
+}
+
+// alertTopProcesses количество процессов в уведомлениях о нагрузке CPU и памяти
+const alertTopProcesses = 5
+
+// topProcesses формирует список процессов для уведомления
+func topProcesses(s *Service, list func(top *system.TopProcesses) []*system.ProcessInfo) string {
+	top, err := s.systemService.GetTopProcesses(alertTopProcesses)
+	if err != nil {
+		log.Printf("Monitoring: Ошибка получения списка процессов: %v", err)
+		return ""
+	}
+
+	result := "Топ процессов:\n"
+	for _, proc := range list(top) {
+		result += "• " + proc.Summary() + "\n"
+	}
+	return result
+}
+
+// collectDisks получает значение метрики для каждой точки монтирования
+func collectDisks(m *system.Monitor, value func(d *system.DiskInfo) float64) ([]metricSample, error) {
+	diskInfos, err := m.GetDiskInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]metricSample, 0, len(diskInfos))
+	for _, diskInfo := range diskInfos {
+		samples = append(samples, metricSample{instance: diskInfo.MountPoint, value: value(diskInfo)})
+	}
+	return samples, nil
+}
+
+// forecastHorizonHours предел прогноза заполнения диска; более далекое заполнение считается неожидаемым
+const forecastHorizonHours = 365 * 24
+
+// legacyDiskExclude точки монтирования, не проверяемые правилом из глобального порога диска
+var legacyDiskExclude = []string{"/boot", "/boot/*", "/snap/*"}
+
+// Rule правило алерта, подготовленное для проверки
+type Rule struct {
+	Name     string
+	Metric   string
+	selector string
+	exclude  []string
+	source   metricSource
+	alert    alertRule
+	chats    []int64
+	channels []string
+}
+
+// matches проверяет, подходит ли экземпляр метрики под селектор правила
+func (r *Rule) matches(instance string) bool {
+	if r.selector != "" && !globMatch(r.selector, instance) {
+		return false
+	}
+	for _, pattern := range r.exclude {
+		if globMatch(pattern, instance) {
+			return false
+		}
+	}
+	return true
+}
+
+// globMatch сравнивает строку с шаблоном; ошибка в шаблоне считается несовпадением
+func globMatch(pattern, s string) bool {
+	matched, err := path.Match(pattern, s)
+	return err == nil && matched
+}
+
+// buildRules подготавливает правила алертов из конфигурации
+// Если правила не заданы, они строятся из глобальных порогов
+func buildRules(cfg config.MonitoringConfig) []*Rule {
+	ruleConfigs := cfg.Rules
+	if len(ruleConfigs) == 0 {
+		ruleConfigs = legacyRules(cfg)
+	}
+
+	rules := make([]*Rule, 0, len(ruleConfigs))
+	names := make(map[string]bool)
+	for _, ruleConfig := range ruleConfigs {
+		rule, err := buildRule(ruleConfig, cfg)
+		if err != nil {
+			log.Printf("Monitoring: Правило %q пропущено: %v", ruleConfig.Name, err)
+			continue
+		}
+
+		// Имя используется как ключ алерта, поэтому должно быть уникальным
+		name := rule.Name
+		for i := 2; names[rule.Name]; i++ {
+			rule.Name = fmt.Sprintf("%s-%d", name, i)
+		}
+		names[rule.Name] = true
+
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// buildRule проверяет правило из конфигурации и подготавливает его для проверки
+func buildRule(ruleConfig config.AlertRuleConfig, cfg config.MonitoringConfig) (*Rule, error) {
+	source, ok := metricSources[ruleConfig.Metric]
+	if !ok {
+		return nil, fmt.Errorf("неизвестная метрика %q", ruleConfig.Metric)
+	}
+
+	operator := ruleConfig.Operator
+	if operator == "" {
+		operator = ">"
+	}
+	if operator != ">" && operator != "<" {
+		return nil, fmt.Errorf("неизвестный оператор %q, ожидается > или <", operator)
+	}
+
+	var levels []alertLevel
+	if ruleConfig.Critical != nil {
+		levels = append(levels, alertLevel{severity: notify.SeverityCritical, threshold: *ruleConfig.Critical})
+	}
+	if ruleConfig.Warning != nil {
+		levels = append(levels, alertLevel{severity: notify.SeverityWarning, threshold: *ruleConfig.Warning})
+	}
+	if ruleConfig.Threshold != nil {
+		severity := ruleConfig.Severity
+		if severity == "" {
+			severity = notify.SeverityWarning
+		}
+		if severityRank(severity) == 0 {
+			return nil, fmt.Errorf("неизвестный уровень %q", severity)
+		}
+		levels = append(levels, alertLevel{severity: severity, threshold: *ruleConfig.Threshold})
+	}
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("не задан ни один порог")
+	}
+	sort.SliceStable(levels, func(i, j int) bool {
+		return severityRank(levels[i].severity) > severityRank(levels[j].severity)
+	})
+
+	// Порог возврата в норму по умолчанию отсчитывается от наименее важного уровня
+	lowest := levels[len(levels)-1].threshold
+	clear := lowest - source.hysteresis
+	if operator == "<" {
+		clear = lowest + source.hysteresis
+	}
+	if ruleConfig.Clear != nil {
+		clear = *ruleConfig.Clear
+	}
+
+	duration := cfg.AlertDuration
+	if ruleConfig.Duration != nil {
+		duration = *ruleConfig.Duration
+	}
+	repeat := cfg.RepeatInterval
+	if ruleConfig.Repeat != nil {
+		repeat = *ruleConfig.Repeat
+	}
+
+	name := ruleConfig.Name
+	if name == "" {
+		name = ruleConfig.Metric
+	}
+
+	return &Rule{
+		Name:     name,
+		Metric:   ruleConfig.Metric,
+		selector: ruleConfig.Selector,
+		exclude:  ruleConfig.Exclude,
+		source:   source,
+		alert: alertRule{
+			operator: operator,
+			levels:   levels,
+			clear:    clear,
+			duration: time.Duration(duration) * time.Second,
+			repeat:   time.Duration(repeat) * time.Second,
+		},
+		chats:    ruleConfig.Chats,
+		channels: ruleConfig.Channels,
+	}, nil
+}
+
+// legacyRules строит правила из глобальных порогов конфигурации
+func legacyRules(cfg config.MonitoringConfig) []config.AlertRuleConfig {
+	var rules []config.AlertRuleConfig
+
+	legacy := func(name, metric, operator string, threshold, clear int, exclude []string) {
+		if threshold <= 0 {
+			return
+		}
+		rule := config.AlertRuleConfig{
+			Name:      name,
+			Metric:    metric,
+			Operator:  operator,
+			Threshold: floatPtr(float64(threshold)),
+			Exclude:   exclude,
+		}
+		if clear > 0 {
+			rule.Clear = floatPtr(float64(clear))
+		}
+		rules = append(rules, rule)
+	}
+
+	legacy("cpu", "cpu", ">", cfg.CPUThreshold, cfg.CPUClearThreshold, nil)
+	legacy("memory", "memory", ">", cfg.MemoryThreshold, cfg.MemoryClearThreshold, nil)
+	// Порог диска задан для свободного места, поэтому алерт срабатывает при значении ниже порога
+	legacy("disk", "disk_free", "<", cfg.DiskThreshold, cfg.DiskClearThreshold, legacyDiskExclude)
+	legacy("inode", "inode_used", ">", cfg.InodeThreshold, cfg.InodeClearThreshold, legacyDiskExclude)
+
+	return rules
+}
+
+// floatPtr возвращает указатель на значение
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+// Threshold порог правила алерта для отображения на графиках
+type Threshold struct {
+	Rule     string
+	Severity string
+	Value    float64
+}
+
+// Thresholds возвращает пороги всех правил для метрики
+func (s *Service) Thresholds(metric string) []Threshold {
+	var thresholds []Threshold
+	for _, rule := range s.rules {
+		if rule.Metric != metric {
+			continue
+		}
+		for _, level := range rule.alert.levels {
+			thresholds = append(thresholds, Threshold{Rule: rule.Name, Severity: level.severity, Value: level.threshold})
+		}
+	}
+	return thresholds
+}
@@ -1,38 +1,66 @@
 package monitoring
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"tgbot/internal/services/docker"
+	"tgbot/internal/services/metrics"
+	"tgbot/internal/services/notify"
 	"tgbot/internal/services/system"
+	"tgbot/internal/services/traffic"
 	"tgbot/pkg/config"
+	"tgbot/pkg/schedule"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
 )
 
+// defaultHysteresis разница между порогом срабатывания и возврата в норму по умолчанию (в процентах)
+const defaultHysteresis = 5
+
 // Service сервис мониторинга системных событий
 type Service struct {
-	bot           *tgbotapi.BotAPI
-	config        *config.Config
-	systemService *system.Monitor
-	chatID        int64
-	stopChan      chan struct{}
+	router            *notify.Router
+	config            *config.Config
+	systemService     *system.Monitor
+	dockerService     *docker.Manager
+	metricsStore      *metrics.Store
+	trafficAccountant *traffic.Accountant
+	rules             []*Rule
+	alerts            map[string]*Alert
+	silences          map[string]*Silence
+	history           []Incident
+	mu                sync.Mutex
+	stopChan          chan struct{}
 }
 
 // NewService создает новый сервис мониторинга
-func NewService(bot *tgbotapi.BotAPI, cfg *config.Config, systemService *system.Monitor, chatID int64) *Service {
+// metricsStore может быть nil, если история метрик отключена
+// trafficAccountant используется правилами квоты трафика
+func NewService(router *notify.Router, cfg *config.Config, systemService *system.Monitor, dockerService *docker.Manager, metricsStore *metrics.Store, trafficAccountant *traffic.Accountant) *Service {
 	return &Service{
-		bot:           bot,
-		config:        cfg,
-		systemService: systemService,
-		chatID:        chatID,
-		stopChan:      make(chan struct{}),
+		router:            router,
+		config:            cfg,
+		systemService:     systemService,
+		dockerService:     dockerService,
+		metricsStore:      metricsStore,
+		trafficAccountant: trafficAccountant,
+		rules:             buildRules(cfg.Monitoring),
+		alerts:            make(map[string]*Alert),
+		silences:          make(map[string]*Silence),
+		stopChan:          make(chan struct{}),
 	}
 }
 
 // Start запускает сервис мониторинга
 func (s *Service) Start() {
+	s.loadSilences()
+	s.loadHistory()
 	go s.monitor()
 }
 
@@ -56,69 +84,272 @@ func (s *Service) monitor() {
 	}
 }
 
-// checkSystemMetrics проверяет системные метрики и отправляет уведомления при достижении пороговых значений
+// checkSystemMetrics проверяет системные метрики по правилам и отправляет уведомления при изменении состояния алертов
 func (s *Service) checkSystemMetrics() {
-	// Проверка загрузки CPU
-	if s.config.Monitoring.CPUThreshold > 0 {
-		cpuInfo, err := s.systemService.GetCPUInfo()
-		if err != nil {
-			log.Printf("Monitoring: Ошибка получения информации о CPU: %v", err)
-			return
+	now := time.Now()
+
+	// Каждая метрика собирается один раз, даже если на неё ссылаются несколько правил
+	samples := make(map[string][]metricSample)
+	failed := make(map[string]bool)
+
+	for _, rule := range s.rules {
+		if failed[rule.Metric] {
+			continue
+		}
+		metricSamples, ok := samples[rule.Metric]
+		if !ok {
+			var err error
+			metricSamples, err = rule.source.collect(s)
+			if err != nil {
+				log.Printf("Monitoring: Ошибка получения метрики %s: %v", rule.Metric, err)
+				failed[rule.Metric] = true
+				continue
+			}
+			samples[rule.Metric] = metricSamples
 		}
 
-		if cpuInfo.Load > float64(s.config.Monitoring.CPUThreshold) {
-			message := fmt.Sprintf("⚠️ Высокая нагрузка на CPU: %.2f%% (порог: %d%%)", cpuInfo.Load, s.config.Monitoring.CPUThreshold)
-			s.sendNotification(message)
+		present := make(map[string]bool)
+		for _, sample := range metricSamples {
+			if !rule.matches(sample.instance) {
+				continue
+			}
+			present[sample.instance] = true
+			if !sample.unknown {
+				s.evaluate(rule, sample, now)
+			}
 		}
+		s.expire(rule, present, now)
 	}
+}
 
-	// Проверка использования памяти
-	if s.config.Monitoring.MemoryThreshold > 0 {
-		memInfo, err := s.systemService.GetMemoryInfo()
-		if err != nil {
-			log.Printf("Monitoring: Ошибка получения информации о памяти: %v", err)
-			return
+// expire завершает алерты правила по экземплярам, которых больше нет среди значений метрики
+func (s *Service) expire(rule *Rule, present map[string]bool, now time.Time) {
+	prefix := rule.Name + ":"
+
+	type resolvedAlert struct {
+		notification notify.Notification
+		escalated    bool
+	}
+	var resolved []resolvedAlert
+
+	s.mu.Lock()
+	for key, alert := range s.alerts {
+		instance := strings.TrimPrefix(key, prefix)
+		if !strings.HasPrefix(key, prefix) || present[instance] {
+			continue
+		}
+		duration := schedule.FormatDuration(now.Sub(alert.PendingSince))
+		escalated := alert.Escalated
+		event := alert.Vanish(now)
+		s.recordIncident(alert, event, now)
+		delete(s.alerts, key)
+		if event != EventResolved {
+			continue
 		}
 
-		if memInfo.UsedPercent > float64(s.config.Monitoring.MemoryThreshold) {
-			message := fmt.Sprintf("⚠️ Высокое использование памяти: %.2f%% (порог: %d%%)", memInfo.UsedPercent, s.config.Monitoring.MemoryThreshold)
-			s.sendNotification(message)
+		log.Printf("Monitoring: Алерт %s завершен: экземпляр метрики исчез", key)
+		if s.silenced(key, now) {
+			continue
 		}
+		resolved = append(resolved, resolvedAlert{
+			notification: notify.Notification{
+				Source:   rule.source.source,
+				Severity: alert.Severity,
+				Chats:    rule.chats,
+				Channels: rule.channels,
+				Text: fmt.Sprintf("✅ %s %s больше не наблюдается, алерт завершен (длительность инцидента: %s)",
+					rule.source.title, instance, duration),
+			},
+			escalated: escalated,
+		})
 	}
+	s.mu.Unlock()
 
-	// Проверка использования диска
-	if s.config.Monitoring.DiskThreshold > 0 {
-		diskInfos, err := s.systemService.GetDiskInfo()
-		if err != nil {
-			log.Printf("Monitoring: Ошибка получения информации о дисках: %v", err)
-			return
+	for _, alert := range resolved {
+		s.notifyResolved(alert.notification, alert.escalated)
+	}
+}
+
+// notifyResolved отправляет уведомление о возврате в норму, а если алерт был эскалирован - и в канал эскалации
+func (s *Service) notifyResolved(notification notify.Notification, escalated bool) {
+	s.router.Send(notification)
+	if escalated {
+		if err := s.router.SendToChannel(s.config.Notifications.Escalation.Channel, notification); err != nil && !errors.Is(err, notify.ErrSuppressed) {
+			log.Printf("Monitoring: Ошибка отправки в канал эскалации: %v", err)
 		}
+	}
+}
+
+// evaluate обновляет состояние алерта для экземпляра метрики и отправляет уведомление о событии
+func (s *Service) evaluate(rule *Rule, sample metricSample, now time.Time) {
+	key := rule.Name
+	if sample.instance != "" {
+		key += ":" + sample.instance
+	}
 
-		for _, diskInfo := range diskInfos {
-			// Проверяем, что свободное место меньше порога (100 - usedPercent > threshold)
-			freePercent := 100 - diskInfo.UsedPercent
-			if freePercent < float64(s.config.Monitoring.DiskThreshold) {
-				message := fmt.Sprintf("⚠️ Мало свободного места на диске %s: %.2f%% свободно (порог: %d%%)",
-					diskInfo.MountPoint, freePercent, s.config.Monitoring.DiskThreshold)
-				s.sendNotification(message)
+	subject := rule.source.title
+	if sample.instance != "" {
+		subject += " " + sample.instance
+	}
+	value := rule.source.formatValue(sample.value)
+
+	s.mu.Lock()
+	alert := s.alert(key)
+	event := alert.Evaluate(rule.alert, sample.value, now)
+	s.recordIncident(alert, event, now)
+	severity := alert.Severity
+	acknowledged := alert.AcknowledgedBy != ""
+	duration := schedule.FormatDuration(now.Sub(alert.PendingSince))
+	// Состояние алерта обновляется и во время заглушки, подавляются только уведомления
+	silenced := s.silenced(key, now)
+	escalate := !silenced && s.shouldEscalate(alert, now)
+	escalated := alert.Escalated
+	s.mu.Unlock()
+
+	notification := notify.Notification{
+		Source:   rule.source.source,
+		Severity: severity,
+		Chats:    rule.chats,
+		Channels: rule.channels,
+	}
+
+	if escalate {
+		escalation := notification
+		escalation.Text = fmt.Sprintf("⏫ Эскалация: алерт не подтвержден %s\n%s %s: %s", duration, severityLabel(severity), subject, value)
+		escalation.Keyboard = alertKeyboard(key)
+		err := s.router.SendToChannel(s.config.Notifications.Escalation.Channel, escalation)
+		// Подавленная тихими часами эскалация повторяется после их окончания
+		if !errors.Is(err, notify.ErrSuppressed) {
+			if err != nil {
+				log.Printf("Monitoring: Ошибка эскалации алерта %s: %v", key, err)
+			}
+			s.mu.Lock()
+			if alert.State == AlertFiring {
+				alert.Escalated = true
 			}
+			s.mu.Unlock()
 		}
 	}
-}
 
-// sendNotification отправляет уведомление в Telegram
-func (s *Service) sendNotification(message string) {
-	msg := tgbotapi.NewMessage(s.chatID, message)
-	_, err := s.bot.Send(msg)
-	if err != nil {
-		// Попытка отправить уведомление об ошибке администратору
-		errorMsg := fmt.Sprintf("❌ Ошибка отправки уведомления: %v\nСообщение: %s", err, message)
-		log.Printf(errorMsg)
-
-		// Если у нас есть список разрешенных чатов, попробуем отправить в первый из них
-		if len(s.config.Bot.AllowedChats) > 0 {
-			msg := tgbotapi.NewMessage(s.config.Bot.AllowedChats[0], errorMsg)
-			s.bot.Send(msg)
+	if silenced {
+		return
+	}
+
+	switch event {
+	case EventFiring:
+		notification.Text = fmt.Sprintf("%s %s: %s (порог: %s)", severityLabel(severity), subject, value,
+			rule.source.formatValue(rule.alert.threshold(severity)))
+	case EventEscalated:
+		notification.Text = fmt.Sprintf("%s %s: %s, уровень повышен (порог: %s, длится %s)", severityLabel(severity), subject, value,
+			rule.source.formatValue(rule.alert.threshold(severity)), duration)
+	case EventReminder:
+		// Подтвержденный алерт не напоминает о себе до возврата в норму
+		if acknowledged {
+			return
+		}
+		notification.Text = fmt.Sprintf("🔁 %s всё ещё за порогом: %s (длится %s)", subject, value, duration)
+	case EventResolved:
+		notification.Text = fmt.Sprintf("✅ %s в норме: %s (длительность инцидента: %s)", subject, value, duration)
+	default:
+		return
+	}
+
+	// К срабатыванию добавляются подробности, например процессы с наибольшей нагрузкой
+	if (event == EventFiring || event == EventEscalated) && rule.source.details != nil {
+		if details := rule.source.details(s); details != "" {
+			notification.Text += "\n\n" + details
 		}
 	}
+	if event != EventResolved {
+		notification.Keyboard = alertKeyboard(key)
+	}
+	if event == EventResolved {
+		s.notifyResolved(notification, escalated)
+		return
+	}
+	s.router.Send(notification)
+}
+
+// shouldEscalate проверяет, пора ли эскалировать неподтвержденный алерт
+// Эскалация отмечается после доставки; вызывается с захваченной блокировкой
+func (s *Service) shouldEscalate(alert *Alert, now time.Time) bool {
+	escalation := s.config.Notifications.Escalation
+	if escalation.Channel == "" || escalation.After <= 0 {
+		return false
+	}
+	if alert.State != AlertFiring || alert.AcknowledgedBy != "" || alert.Escalated {
+		return false
+	}
+	if now.Sub(alert.FiringSince) < time.Duration(escalation.After)*time.Second {
+		return false
+	}
+	return true
+}
+
+// alertKeyboard создает кнопки подтверждения и заглушки алерта
+func alertKeyboard(key string) *tgbotapi.InlineKeyboardMarkup {
+	id := AlertID(key)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("👌 Принято", "alert_ack:"+id),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔕 1 ч", "alert_silence:1:"+id),
+			tgbotapi.NewInlineKeyboardButtonData("🔕 4 ч", "alert_silence:4:"+id),
+			tgbotapi.NewInlineKeyboardButtonData("🔕 24 ч", "alert_silence:24:"+id),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔔 Снять заглушку", "alert_unsilence:"+id),
+		),
+	)
+	return &keyboard
+}
+
+// severityLabel возвращает подпись уровня важности для уведомления
+func severityLabel(severity string) string {
+	switch severity {
+	case notify.SeverityCritical:
+		return "🔴 Критично:"
+	case notify.SeverityInfo:
+		return "ℹ️"
+	default:
+		return "⚠️"
+	}
+}
+
+// formatValue форматирует значение метрики с единицей измерения
+func (m metricSource) formatValue(value float64) string {
+	if m.format != nil {
+		return m.format(value)
+	}
+	if m.unit == "" {
+		return fmt.Sprintf("%g", value)
+	}
+	return fmt.Sprintf("%.2f%s", value, m.unit)
+}
+
+// alert возвращает состояние алерта по ключу, создавая его при необходимости
+// Вызывается с захваченной блокировкой
+func (s *Service) alert(key string) *Alert {
+	alert, ok := s.alerts[key]
+	if !ok {
+		alert = &Alert{Key: key}
+		s.alerts[key] = alert
+	}
+	return alert
+}
+
+// Alerts возвращает копию состояний всех алертов, отсортированную по ключу
+func (s *Service) Alerts() []Alert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alerts := make([]Alert, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		alerts = append(alerts, *alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Key < alerts[j].Key
+	})
+	return alerts
 }
@@ -0,0 +1,182 @@
+package monitoring
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"tgbot/pkg/storage"
+)
+
+// silencesFileName файл активных заглушек алертов в директории данных
+const silencesFileName = "alert-silences.json"
+
+// Silence заглушка уведомлений по алерту
+type Silence struct {
+	Key       string    `json:"key"`
+	Until     time.Time `json:"until"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by"`
+}
+
+// AlertID возвращает короткий идентификатор алерта для callback-данных кнопок
+// Ключ алерта может содержать длинный путь и не помещаться в ограничение Telegram на 64 байта
+func AlertID(key string) string {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// Acknowledge подтверждает активный алерт; напоминания по нему не отправляются до возврата в норму
+func (s *Service) Acknowledge(id, by string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := s.findKey(id)
+	alert, ok := s.alerts[key]
+	if !ok {
+		return "", fmt.Errorf("алерт не найден")
+	}
+	if alert.State != AlertFiring {
+		return key, fmt.Errorf("алерт %s уже не активен", key)
+	}
+
+	alert.AcknowledgedBy = by
+	return key, nil
+}
+
+// Silence отключает уведомления по алерту на указанное время
+func (s *Service) Silence(id string, duration time.Duration, by string) (*Silence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := s.findKey(id)
+	if key == "" {
+		return nil, fmt.Errorf("алерт не найден")
+	}
+
+	now := time.Now()
+	silence := &Silence{Key: key, Until: now.Add(duration), CreatedAt: now, CreatedBy: by}
+	s.silences[key] = silence
+	if err := s.saveSilences(); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения заглушки: %v", err)
+	}
+
+	result := *silence
+	return &result, nil
+}
+
+// Unsilence снимает заглушку с алерта
+func (s *Service) Unsilence(id string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := s.findKey(id)
+	if _, ok := s.silences[key]; !ok {
+		return key, false
+	}
+
+	delete(s.silences, key)
+	if err := s.saveSilences(); err != nil {
+		log.Printf("Monitoring: Ошибка сохранения заглушек: %v", err)
+	}
+	return key, true
+}
+
+// Silences возвращает активные заглушки, отсортированные по времени окончания
+func (s *Service) Silences() []Silence {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneSilences(time.Now())
+
+	silences := make([]Silence, 0, len(s.silences))
+	for _, silence := range s.silences {
+		silences = append(silences, *silence)
+	}
+	sort.Slice(silences, func(i, j int) bool {
+		return silences[i].Until.Before(silences[j].Until)
+	})
+	return silences
+}
+
+// silenced проверяет, заглушены ли уведомления по алерту
+// Вызывается с захваченной блокировкой
+func (s *Service) silenced(key string, now time.Time) bool {
+	silence, ok := s.silences[key]
+	if !ok {
+		return false
+	}
+	if now.After(silence.Until) {
+		s.pruneSilences(now)
+		return false
+	}
+	return true
+}
+
+// findKey находит ключ алерта по короткому идентификатору среди алертов и заглушек
+// Вызывается с захваченной блокировкой
+func (s *Service) findKey(id string) string {
+	for key := range s.alerts {
+		if AlertID(key) == id {
+			return key
+		}
+	}
+	for key := range s.silences {
+		if AlertID(key) == id {
+			return key
+		}
+	}
+	return ""
+}
+
+// pruneSilences удаляет истекшие заглушки
+// Вызывается с захваченной блокировкой
+func (s *Service) pruneSilences(now time.Time) {
+	changed := false
+	for key, silence := range s.silences {
+		if now.After(silence.Until) {
+			delete(s.silences, key)
+			changed = true
+		}
+	}
+	if changed {
+		if err := s.saveSilences(); err != nil {
+			log.Printf("Monitoring: Ошибка сохранения заглушек: %v", err)
+		}
+	}
+}
+
+// loadSilences загружает заглушки, сохраненные до перезапуска бота
+func (s *Service) loadSilences() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var silences []*Silence
+	if err := storage.LoadJSON(s.silencesPath(), &silences); err != nil {
+		log.Printf("Monitoring: Ошибка загрузки заглушек: %v", err)
+		return
+	}
+	for _, silence := range silences {
+		s.silences[silence.Key] = silence
+	}
+	s.pruneSilences(time.Now())
+}
+
+// saveSilences сохраняет активные заглушки
+// Вызывается с захваченной блокировкой
+func (s *Service) saveSilences() error {
+	silences := make([]*Silence, 0, len(s.silences))
+	for _, silence := range s.silences {
+		silences = append(silences, silence)
+	}
+	return storage.SaveJSON(s.silencesPath(), silences)
+}
+
+// silencesPath возвращает путь к файлу заглушек
+func (s *Service) silencesPath() string {
+	return filepath.Join(s.config.Storage.DataDir, silencesFileName)
+}
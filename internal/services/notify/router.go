@@ -0,0 +1,234 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"tgbot/pkg/config"
+	"tgbot/pkg/schedule"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Уровни важности уведомлений
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Источники уведомлений
+const (
+	SourceSystem  = "system"
+	SourceDocker  = "docker"
+	SourceSystemd = "systemd"
+	SourceKernel  = "kernel"
+	SourceAuth    = "auth"
+)
+
+// ErrSuppressed возвращается, если уведомление не доставлено из-за тихих часов
+var ErrSuppressed = errors.New("уведомление подавлено тихими часами")
+
+// Notification уведомление для доставки по маршрутам
+type Notification struct {
+	Source   string
+	Severity string
+	Text     string
+	Keyboard *tgbotapi.InlineKeyboardMarkup
+	// Chats и Channels задают получателей явно, в обход маршрутов
+	Chats    []int64
+	Channels []string
+	// IgnoreQuietHours доставляет уведомление и в тихие часы, например запрошенные по расписанию отчеты
+	IgnoreQuietHours bool
+}
+
+// Channel канал доставки уведомлений
+type Channel struct {
+	Name     string
+	ChatID   int64
+	ThreadID int
+}
+
+// Router доставляет уведомления в каналы согласно маршрутам и тихим часам
+type Router struct {
+	bot         *tgbotapi.BotAPI
+	config      config.NotificationsConfig
+	defaultChat int64
+	quietStart  *schedule.Clock
+	quietEnd    *schedule.Clock
+}
+
+// NewRouter создает маршрутизатор уведомлений
+// Уведомления, не подходящие ни под один маршрут, отправляются в чат по умолчанию
+func NewRouter(bot *tgbotapi.BotAPI, cfg config.NotificationsConfig, defaultChat int64) *Router {
+	r := &Router{
+		bot:         bot,
+		config:      cfg,
+		defaultChat: defaultChat,
+	}
+
+	if cfg.QuietHours.Start != "" || cfg.QuietHours.End != "" {
+		start, errStart := schedule.ParseClock(cfg.QuietHours.Start)
+		end, errEnd := schedule.ParseClock(cfg.QuietHours.End)
+		if errStart != nil || errEnd != nil {
+			log.Printf("Notify: Тихие часы отключены: некорректный интервал %q-%q", cfg.QuietHours.Start, cfg.QuietHours.End)
+		} else {
+			r.quietStart, r.quietEnd = &start, &end
+		}
+	}
+
+	for _, route := range cfg.Routes {
+		for _, name := range route.Channels {
+			if _, ok := cfg.Channels[strings.ToLower(name)]; !ok {
+				log.Printf("Notify: Маршрут ссылается на неизвестный канал %q", name)
+			}
+		}
+	}
+
+	return r
+}
+
+// Send доставляет уведомление по маршрутам
+func (r *Router) Send(n Notification) {
+	if !n.IgnoreQuietHours && r.Quiet(n.Severity, time.Now()) {
+		log.Printf("Notify: Тихие часы, уведомление не отправлено: %s", n.Text)
+		return
+	}
+
+	for _, channel := range r.destinations(n) {
+		r.deliver(channel, n)
+	}
+}
+
+// SendToChannel доставляет уведомление в именованный канал, например при эскалации
+// В тихие часы возвращает ErrSuppressed, чтобы отправитель мог повторить доставку позже
+func (r *Router) SendToChannel(name string, n Notification) error {
+	channel, ok := r.channel(name)
+	if !ok {
+		return fmt.Errorf("неизвестный канал %q", name)
+	}
+	if !n.IgnoreQuietHours && r.Quiet(n.Severity, time.Now()) {
+		return ErrSuppressed
+	}
+
+	r.deliver(channel, n)
+	return nil
+}
+
+// Quiet проверяет, должно ли уведомление быть подавлено тихими часами
+func (r *Router) Quiet(severity string, now time.Time) bool {
+	if r.quietStart == nil || severity == SeverityCritical {
+		return false
+	}
+	return schedule.Within(*r.quietStart, *r.quietEnd, now)
+}
+
+// destinations определяет каналы доставки уведомления без повторов
+func (r *Router) destinations(n Notification) []Channel {
+	var channels []Channel
+	seen := make(map[string]bool)
+	add := func(channel Channel) {
+		key := fmt.Sprintf("%d/%d", channel.ChatID, channel.ThreadID)
+		if !seen[key] {
+			seen[key] = true
+			channels = append(channels, channel)
+		}
+	}
+	addNamed := func(names []string) {
+		for _, name := range names {
+			if channel, ok := r.channel(name); ok {
+				add(channel)
+			}
+		}
+	}
+
+	if len(n.Chats) > 0 || len(n.Channels) > 0 {
+		for _, chatID := range n.Chats {
+			add(Channel{ChatID: chatID})
+		}
+		addNamed(n.Channels)
+	} else {
+		for _, route := range r.config.Routes {
+			if matches(route.Severity, n.Severity) && matches(route.Source, n.Source) {
+				addNamed(route.Channels)
+			}
+		}
+	}
+
+	if len(channels) == 0 {
+		add(Channel{Name: "default", ChatID: r.defaultChat})
+	}
+	return channels
+}
+
+// channel возвращает именованный канал; имена нечувствительны к регистру, как и ключи конфигурации
+func (r *Router) channel(name string) (Channel, bool) {
+	channelConfig, ok := r.config.Channels[strings.ToLower(name)]
+	if !ok {
+		return Channel{}, false
+	}
+	return Channel{Name: name, ChatID: channelConfig.ChatID, ThreadID: channelConfig.ThreadID}, true
+}
+
+// deliver отправляет уведомление в канал
+func (r *Router) deliver(channel Channel, n Notification) {
+	err := r.sendMessage(channel, n.Text, n.Keyboard)
+	if err == nil {
+		return
+	}
+
+	errorMsg := fmt.Sprintf("❌ Ошибка отправки уведомления в канал %s: %v\nСообщение: %s", channel.Name, err, n.Text)
+	log.Print(errorMsg)
+
+	// Сообщаем об ошибке в чат по умолчанию, если отправка была в другой чат
+	if channel.ChatID != r.defaultChat && r.defaultChat != 0 {
+		r.bot.Send(tgbotapi.NewMessage(r.defaultChat, errorMsg))
+	}
+}
+
+// sendMessage отправляет сообщение в чат или тему группы
+func (r *Router) sendMessage(channel Channel, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	if channel.ThreadID == 0 {
+		msg := tgbotapi.NewMessage(channel.ChatID, text)
+		if keyboard != nil {
+			msg.ReplyMarkup = keyboard
+		}
+		_, err := r.bot.Send(msg)
+		return err
+	}
+
+	// Версия библиотеки не поддерживает темы, поэтому запрос формируется вручную
+	params := url.Values{}
+	params.Set("chat_id", strconv.FormatInt(channel.ChatID, 10))
+	params.Set("message_thread_id", strconv.Itoa(channel.ThreadID))
+	params.Set("text", text)
+	if keyboard != nil {
+		data, err := json.Marshal(keyboard)
+		if err != nil {
+			return err
+		}
+		params.Set("reply_markup", string(data))
+	}
+
+	_, err := r.bot.MakeRequest("sendMessage", params)
+	return err
+}
+
+// matches проверяет значение по списку условий маршрута; пустой список подходит под любое значение
+func matches(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,421 @@
+package report
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tgbot/internal/services/docker"
+	"tgbot/internal/services/metrics"
+	"tgbot/internal/services/monitoring"
+	"tgbot/internal/services/notify"
+	"tgbot/internal/services/system"
+	"tgbot/pkg/config"
+	"tgbot/pkg/schedule"
+	"tgbot/pkg/storage"
+)
+
+// digestStateFileName файл состояния сводок в директории данных
+const digestStateFileName = "digest-state.json"
+
+// digestCheckInterval период проверки расписания сводок
+const digestCheckInterval = 30 * time.Second
+
+// maxDigestIncidents максимальное количество инцидентов в сводке
+const maxDigestIncidents = 10
+
+// digestKind вид периодической сводки
+type digestKind struct {
+	name   string
+	title  string
+	period time.Duration
+	// next возвращает время следующей отправки после now
+	next func(now time.Time) time.Time
+}
+
+// digestState состояние сводки, сохраняемое между запусками
+type digestState struct {
+	SentAt time.Time `json:"sent_at"`
+	// RestartCounts количество перезапусков контейнеров на момент отправки
+	RestartCounts map[string]int `json:"restart_counts"`
+	// Fail2banBans общее количество блокировок по jail fail2ban на момент отправки
+	Fail2banBans map[string]int `json:"fail2ban_bans,omitempty"`
+}
+
+// Digester отправляет ежедневные и еженедельные сводки о состоянии сервера
+type Digester struct {
+	router        *notify.Router
+	config        config.DigestConfig
+	fail2ban      config.Fail2banConfig
+	systemService *system.Monitor
+	dockerService *docker.Manager
+	monitoring    *monitoring.Service
+	metricsStore  *metrics.Store
+	statePath     string
+	kinds         []digestKind
+	nextAt        map[string]time.Time
+	mu            sync.Mutex
+	stopChan      chan struct{}
+}
+
+// NewDigester создает сервис периодических сводок
+// metricsStore может быть nil, тогда сводка не содержит статистики метрик
+func NewDigester(router *notify.Router, cfg *config.Config, systemService *system.Monitor, dockerService *docker.Manager, monitoringService *monitoring.Service, metricsStore *metrics.Store) *Digester {
+	d := &Digester{
+		router:        router,
+		config:        cfg.Digest,
+		fail2ban:      cfg.Fail2ban,
+		systemService: systemService,
+		dockerService: dockerService,
+		monitoring:    monitoringService,
+		metricsStore:  metricsStore,
+		statePath:     filepath.Join(cfg.Storage.DataDir, digestStateFileName),
+		nextAt:        make(map[string]time.Time),
+		stopChan:      make(chan struct{}),
+	}
+
+	if cfg.Digest.DailyTime != "" {
+		clock, err := schedule.ParseClock(cfg.Digest.DailyTime)
+		if err != nil {
+			log.Printf("Report: Ежедневная сводка отключена: %v", err)
+		} else {
+			d.kinds = append(d.kinds, digestKind{
+				name:   "daily",
+				title:  "Ежедневная сводка",
+				period: 24 * time.Hour,
+				next:   clock.Next,
+			})
+		}
+	}
+
+	if cfg.Digest.WeeklyDay != "" {
+		weeklyTime := cfg.Digest.WeeklyTime
+		if weeklyTime == "" {
+			weeklyTime = "09:00"
+		}
+		clock, err := schedule.ParseClock(weeklyTime)
+		weekday, ok := parseWeekday(cfg.Digest.WeeklyDay)
+		switch {
+		case err != nil:
+			log.Printf("Report: Еженедельная сводка отключена: %v", err)
+		case !ok:
+			log.Printf("Report: Еженедельная сводка отключена: неизвестный день недели %q", cfg.Digest.WeeklyDay)
+		default:
+			d.kinds = append(d.kinds, digestKind{
+				name:   "weekly",
+				title:  "Еженедельная сводка",
+				period: 7 * 24 * time.Hour,
+				next: func(now time.Time) time.Time {
+					next := clock.Next(now)
+					for next.Weekday() != weekday {
+						next = next.AddDate(0, 0, 1)
+					}
+					return next
+				},
+			})
+		}
+	}
+
+	return d
+}
+
+// Start запускает отправку сводок по расписанию
+func (d *Digester) Start() {
+	if len(d.kinds) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for _, kind := range d.kinds {
+		d.nextAt[kind.name] = kind.next(now)
+		log.Printf("Report: %s запланирована на %s", kind.title, d.nextAt[kind.name].Format("2006-01-02 15:04"))
+	}
+	go d.run()
+}
+
+// Stop останавливает отправку сводок
+func (d *Digester) Stop() {
+	close(d.stopChan)
+}
+
+// run периодически проверяет расписание сводок
+func (d *Digester) run() {
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			for _, kind := range d.kinds {
+				if now.Before(d.nextAt[kind.name]) {
+					continue
+				}
+				d.nextAt[kind.name] = kind.next(now)
+				d.send(kind, now)
+			}
+		case <-d.stopChan:
+			return
+		}
+	}
+}
+
+// send формирует сводку и отправляет её получателям
+func (d *Digester) send(kind digestKind, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	states := make(map[string]*digestState)
+	if err := storage.LoadJSON(d.statePath, &states); err != nil {
+		log.Printf("Report: Ошибка загрузки состояния сводок: %v", err)
+	}
+
+	state := states[kind.name]
+	message, current := d.buildDigest(kind, state, now)
+
+	d.router.Send(notify.Notification{
+		Source:           notify.SourceSystem,
+		Severity:         notify.SeverityInfo,
+		Text:             message,
+		Chats:            d.config.Chats,
+		Channels:         d.config.Channels,
+		IgnoreQuietHours: true,
+	})
+
+	current.SentAt = now
+	states[kind.name] = current
+	if err := storage.SaveJSON(d.statePath, states); err != nil {
+		log.Printf("Report: Ошибка сохранения состояния сводок: %v", err)
+	}
+}
+
+// buildDigest формирует текст сводки и возвращает текущие счетчики перезапусков контейнеров и блокировок fail2ban
+func (d *Digester) buildDigest(kind digestKind, previous *digestState, now time.Time) (string, *digestState) {
+	from := now.Add(-kind.period)
+	if previous != nil && previous.SentAt.After(from) {
+		from = previous.SentAt
+	}
+
+	message := fmt.Sprintf("📋 %s", kind.title)
+	if hostname, err := os.Hostname(); err == nil {
+		message += fmt.Sprintf(" — %s", hostname)
+	}
+	message += fmt.Sprintf("\nПериод: %s — %s\n\n", from.Format("2006-01-02 15:04"), now.Format("2006-01-02 15:04"))
+
+	if uptime, _, err := d.systemService.GetUptime(); err == nil {
+		message += fmt.Sprintf("🖥 Время работы: %s\n", schedule.FormatDuration(uptime))
+	}
+
+	message += d.formatMetrics(from, now)
+	message += d.formatIncidents(from)
+
+	current := &digestState{}
+	restartCounts, err := d.dockerService.GetRestartCounts()
+	if err != nil {
+		message += fmt.Sprintf("❓ Не удалось проверить контейнеры: %v\n", err)
+	} else {
+		current.RestartCounts = restartCounts
+		message += formatRestarts(previous, restartCounts)
+	}
+
+	failed, err := d.systemService.GetFailedServices()
+	switch {
+	case err != nil:
+		message += fmt.Sprintf("❓ Не удалось проверить сервисы: %v\n", err)
+	case len(failed) > 0:
+		message += fmt.Sprintf("🟥 Сервисы с ошибкой (%d): %s\n", len(failed), strings.Join(failed, ", "))
+	default:
+		message += "✅ Сервисов с ошибкой нет\n"
+	}
+
+	// Пока идет обновление, менеджер пакетов занят, а список обновлений быстро устаревает
+	// Метаданные репозиториев не обновляются: используется список, загруженный менеджером пакетов или /update
+	if d.systemService.UpgradeRunning() {
+		message += "⏳ Выполняется обновление системы\n"
+	} else {
+		updates, err := d.systemService.CachedUpdates()
+		switch {
+		case err != nil:
+			message += fmt.Sprintf("❓ Не удалось проверить обновления: %v\n", err)
+		case len(updates) > 0:
+			security := 0
+			for _, update := range updates {
+				if update.Security {
+					security++
+				}
+			}
+			message += fmt.Sprintf("📦 Доступно обновлений: %d (безопасности: %d)\n", len(updates), security)
+		default:
+			message += "✅ Система обновлена\n"
+		}
+	}
+
+	if status := d.systemService.GetRebootStatus(); status.Required {
+		message += "🔄 Требуется перезагрузка\n"
+	}
+
+	if d.fail2ban.Digest && d.systemService.Fail2banAvailable() {
+		jails, err := d.systemService.GetFail2banJails()
+		if err != nil {
+			message += fmt.Sprintf("❓ Не удалось проверить fail2ban: %v\n", err)
+		} else {
+			current.Fail2banBans = make(map[string]int, len(jails))
+			for _, jail := range jails {
+				current.Fail2banBans[jail.Name] = jail.TotalBanned
+			}
+			message += formatFail2banBans(previous, jails)
+		}
+	}
+
+	return message, current
+}
+
+// formatMetrics формирует раздел сводки со статистикой CPU, памяти и дисков
+func (d *Digester) formatMetrics(from, to time.Time) string {
+	if d.metricsStore == nil {
+		return ""
+	}
+
+	result := ""
+	for _, item := range []struct{ icon, name, series string }{
+		{"💻", "CPU", metrics.SeriesCPU},
+		{"🧠", "RAM", metrics.SeriesMemory},
+	} {
+		points, err := d.metricsStore.Query(item.series, from, to)
+		if err != nil || len(points) == 0 {
+			continue
+		}
+		peak, sum := points[0].Value, 0.0
+		for _, point := range points {
+			if point.Value > peak {
+				peak = point.Value
+			}
+			sum += point.Value
+		}
+		result += fmt.Sprintf("%s %s: сред %.1f%%, пик %.1f%%\n", item.icon, item.name, sum/float64(len(points)), peak)
+	}
+
+	series, err := d.metricsStore.Series(metrics.SeriesDisk)
+	if err == nil && len(series) > 0 {
+		disks := ""
+		for _, name := range series {
+			points, err := d.metricsStore.Query(name, from, to)
+			if err != nil || len(points) == 0 {
+				continue
+			}
+			first, last := points[0].Value, points[len(points)-1].Value
+			disks += fmt.Sprintf("  %s: %.1f%% (%+.1f%% за период)\n", strings.TrimPrefix(name, metrics.SeriesDisk), last, last-first)
+		}
+		if disks != "" {
+			result += "💾 Диски:\n" + disks
+		}
+	}
+
+	if result != "" {
+		result += "\n"
+	}
+	return result
+}
+
+// formatIncidents формирует раздел сводки с алертами за период
+func (d *Digester) formatIncidents(from time.Time) string {
+	incidents := d.monitoring.Incidents(from)
+	if len(incidents) == 0 {
+		return "✅ Алертов за период не было\n"
+	}
+
+	fired, resolved := 0, 0
+	for _, incident := range incidents {
+		if incident.FiredAt.After(from) {
+			fired++
+		}
+		if incident.Resolved() {
+			resolved++
+		}
+	}
+
+	result := fmt.Sprintf("🚨 Алерты: сработало %d, завершено %d\n", fired, resolved)
+	for i, incident := range incidents {
+		if i == maxDigestIncidents {
+			result += fmt.Sprintf("  … и еще %d\n", len(incidents)-maxDigestIncidents)
+			break
+		}
+		if incident.Resolved() {
+			result += fmt.Sprintf("  • %s (%s), длительность %s\n", incident.Key, incident.Severity,
+				schedule.FormatDuration(incident.ResolvedAt.Sub(incident.FiredAt)))
+		} else {
+			result += fmt.Sprintf("  • %s (%s), активен %s\n", incident.Key, incident.Severity,
+				schedule.FormatDuration(time.Since(incident.FiredAt)))
+		}
+	}
+	return result
+}
+
+// formatRestarts формирует раздел сводки с перезапусками контейнеров с момента предыдущей сводки
+func formatRestarts(previous *digestState, counts map[string]int) string {
+	if previous == nil || previous.RestartCounts == nil {
+		return "🐳 Перезапуски контейнеров будут учитываться со следующей сводки\n"
+	}
+
+	var restarts []string
+	for name, count := range counts {
+		// Счетчик сбрасывается при пересоздании контейнера, тогда учитываем текущее значение
+		delta := count - previous.RestartCounts[name]
+		if delta < 0 {
+			delta = count
+		}
+		if delta > 0 {
+			restarts = append(restarts, fmt.Sprintf("%s: %d", name, delta))
+		}
+	}
+	if len(restarts) == 0 {
+		return "🐳 Перезапусков контейнеров не было\n"
+	}
+
+	sort.Strings(restarts)
+	return fmt.Sprintf("🐳 Перезапуски контейнеров: %s\n", strings.Join(restarts, ", "))
+}
+
+// formatFail2banBans формирует раздел сводки с блокировками fail2ban с момента предыдущей сводки
+func formatFail2banBans(previous *digestState, jails []*system.Fail2banJail) string {
+	if previous == nil || previous.Fail2banBans == nil {
+		return "🛡 Блокировки fail2ban будут учитываться со следующей сводки\n"
+	}
+
+	total, banned := 0, 0
+	var bans []string
+	for _, jail := range jails {
+		banned += jail.CurrentlyBanned
+		// Счетчик сбрасывается при перезапуске fail2ban, тогда учитываем текущее значение
+		delta := jail.TotalBanned - previous.Fail2banBans[jail.Name]
+		if delta < 0 {
+			delta = jail.TotalBanned
+		}
+		if delta > 0 {
+			total += delta
+			bans = append(bans, fmt.Sprintf("%s: %d", jail.Name, delta))
+		}
+	}
+	if total == 0 {
+		return fmt.Sprintf("🛡 Новых блокировок fail2ban не было, заблокировано сейчас: %d\n", banned)
+	}
+
+	sort.Strings(bans)
+	return fmt.Sprintf("🛡 Блокировки fail2ban: %d (%s), заблокировано сейчас: %d\n", total, strings.Join(bans, ", "), banned)
+}
+
+// parseWeekday разбирает день недели по английскому названию или его первым трем буквам
+func parseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if s == name || s == name[:3] {
+			return day, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,306 @@
+package report
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"tgbot/internal/services/docker"
+	"tgbot/internal/services/maintenance"
+	"tgbot/internal/services/system"
+	"tgbot/pkg/schedule"
+	"tgbot/pkg/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// stateFileName файл состояния бота в директории данных
+const stateFileName = "bot-state.json"
+
+// heartbeatInterval период сохранения состояния бота
+const heartbeatInterval = time.Minute
+
+// После загрузки сервера Docker запускает контейнеры не сразу, поэтому отчет ждет их запуска
+const (
+	containerSettleTimeout  = 2 * time.Minute
+	containerSettleInterval = 10 * time.Second
+)
+
+// botState состояние бота, сохраняемое между запусками
+type botState struct {
+	StartedAt         time.Time `json:"started_at"`
+	LastSeen          time.Time `json:"last_seen"`
+	CleanShutdown     bool      `json:"clean_shutdown"`
+	RunningContainers []string  `json:"running_containers"`
+}
+
+// StartupReporter отправляет отчет о состоянии сервера при запуске бота
+// и сохраняет состояние, необходимое для следующего отчета
+type StartupReporter struct {
+	bot           *tgbotapi.BotAPI
+	systemService *system.Monitor
+	dockerService *docker.Manager
+	scheduler     *maintenance.Scheduler
+	chatID        int64
+	statePath     string
+	state         botState
+	// stopped означает, что сохранено штатное завершение и состояние больше не перезаписывается
+	stopped  bool
+	mu       sync.Mutex
+	stopChan chan struct{}
+}
+
+// NewStartupReporter создает новый сервис отчетов о запуске
+func NewStartupReporter(bot *tgbotapi.BotAPI, systemService *system.Monitor, dockerService *docker.Manager, scheduler *maintenance.Scheduler, chatID int64, dataDir string) *StartupReporter {
+	return &StartupReporter{
+		bot:           bot,
+		systemService: systemService,
+		dockerService: dockerService,
+		scheduler:     scheduler,
+		chatID:        chatID,
+		statePath:     filepath.Join(dataDir, stateFileName),
+		stopChan:      make(chan struct{}),
+	}
+}
+
+// Start отправляет отчет о запуске и начинает периодически сохранять состояние
+// Отчет отправляется в фоне после запуска контейнеров или истечения времени ожидания
+func (r *StartupReporter) Start() {
+	var previous *botState
+	if err := storage.LoadJSON(r.statePath, &previous); err != nil {
+		log.Printf("Report: Ошибка загрузки состояния бота: %v", err)
+	}
+
+	pending := r.scheduler.TakePendingReboot()
+
+	r.state = botState{StartedAt: time.Now()}
+	// До проверки контейнеров сохраняется прежний список, чтобы не потерять его при повторном сбое
+	if previous != nil {
+		r.state.RunningContainers = previous.RunningContainers
+	}
+	r.saveState(false)
+
+	go r.run(previous, pending)
+}
+
+// run дожидается запуска контейнеров, отправляет отчет о запуске и периодически сохраняет состояние
+func (r *StartupReporter) run(previous *botState, pending *maintenance.PendingReboot) {
+	var expected []string
+	if previous != nil {
+		expected = previous.RunningContainers
+	}
+	running, err := r.waitContainers(expected)
+	select {
+	case <-r.stopChan:
+		return
+	default:
+	}
+
+	message := r.buildStartupReport(previous, pending, running, err)
+	r.send(r.chatID, message)
+	if pending != nil && pending.ChatID != r.chatID {
+		r.send(pending.ChatID, message)
+	}
+
+	// Список запущенных контейнеров становится новым эталоном только после ожидания
+	if err == nil {
+		r.mu.Lock()
+		r.state.RunningContainers = running
+		r.mu.Unlock()
+	}
+	r.saveState(false)
+
+	r.heartbeat()
+}
+
+// waitContainers ждет, пока Docker ответит и запустит ранее работавшие контейнеры, но не дольше containerSettleTimeout
+// Возвращает последний полученный список запущенных контейнеров
+func (r *StartupReporter) waitContainers(expected []string) ([]string, error) {
+	deadline := time.Now().Add(containerSettleTimeout)
+	for {
+		running, err := r.runningContainers()
+		if err == nil && len(difference(expected, running)) == 0 {
+			return running, nil
+		}
+		if time.Now().After(deadline) {
+			return running, err
+		}
+
+		select {
+		case <-time.After(containerSettleInterval):
+		case <-r.stopChan:
+			return running, err
+		}
+	}
+}
+
+// Stop отмечает штатное завершение работы бота
+func (r *StartupReporter) Stop() {
+	close(r.stopChan)
+	r.saveState(true)
+}
+
+// heartbeat периодически сохраняет состояние бота
+func (r *StartupReporter) heartbeat() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.refreshContainers()
+			r.saveState(false)
+		case <-r.stopChan:
+			return
+		}
+	}
+}
+
+// refreshContainers запоминает список запущенных контейнеров
+// При завершении работы список не обновляется, так как контейнеры могут уже останавливаться вместе с сервером
+func (r *StartupReporter) refreshContainers() {
+	running, err := r.runningContainers()
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	r.state.RunningContainers = running
+	r.mu.Unlock()
+}
+
+// saveState сохраняет время последней активности и признак штатного завершения
+func (r *StartupReporter) saveState(clean bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return
+	}
+	r.stopped = clean
+	r.state.LastSeen = time.Now()
+	r.state.CleanShutdown = clean
+
+	if err := storage.SaveJSON(r.statePath, r.state); err != nil {
+		log.Printf("Report: Ошибка сохранения состояния бота: %v", err)
+	}
+}
+
+// buildStartupReport формирует отчет о запуске бота
+// running и containersErr результат проверки контейнеров после ожидания их запуска
+func (r *StartupReporter) buildStartupReport(previous *botState, pending *maintenance.PendingReboot, running []string, containersErr error) string {
+	message := "🚀 Бот запущен\n\n"
+
+	uptime, bootTime, err := r.systemService.GetUptime()
+	if err != nil {
+		message += fmt.Sprintf("❓ Время загрузки недоступно: %v\n", err)
+	} else {
+		message += fmt.Sprintf("🖥 Сервер загружен: %s (время работы %s)\n",
+			bootTime.Format("2006-01-02 15:04:05"), schedule.FormatDuration(uptime))
+	}
+	message += describePreviousShutdown(previous, pending, bootTime) + "\n"
+
+	// Проверка сервисов
+	failed, err := r.systemService.GetFailedServices()
+	switch {
+	case err != nil:
+		message += fmt.Sprintf("\n❓ Не удалось проверить сервисы: %v\n", err)
+	case len(failed) > 0:
+		message += fmt.Sprintf("\n🟥 Сервисы с ошибкой (%d): %s\n", len(failed), strings.Join(failed, ", "))
+	default:
+		message += "\n✅ Все сервисы запущены\n"
+	}
+
+	// Сравнение контейнеров с последним известным состоянием
+	if previous != nil && len(previous.RunningContainers) > 0 {
+		if containersErr != nil {
+			message += fmt.Sprintf("❓ Не удалось проверить контейнеры: %v\n", containersErr)
+		} else if missing := difference(previous.RunningContainers, running); len(missing) > 0 {
+			message += fmt.Sprintf("🐳 Не запустились контейнеры (%d): %s\n", len(missing), strings.Join(missing, ", "))
+		} else {
+			message += fmt.Sprintf("🐳 Все ранее запущенные контейнеры работают (%d)\n", len(previous.RunningContainers))
+		}
+	}
+
+	// Сводка по памяти и дискам
+	if memInfo, err := r.systemService.GetMemoryInfo(); err == nil {
+		message += fmt.Sprintf("\n🧠 RAM: %.2f / %.2f GB (%.1f%%), Swap: %.2f / %.2f GB\n",
+			memInfo.Used, memInfo.Total, memInfo.UsedPercent, memInfo.SwapUsed, memInfo.SwapTotal)
+	}
+	if diskInfos, err := r.systemService.GetDiskInfo(); err == nil {
+		message += "💾 Диски:\n"
+		for _, diskInfo := range diskInfos {
+			message += fmt.Sprintf("  %s: %.1f%% (свободно %.2f GB)\n", diskInfo.MountPoint, diskInfo.UsedPercent, diskInfo.Free)
+		}
+	}
+
+	return message
+}
+
+// describePreviousShutdown определяет, как завершилась предыдущая работа бота
+func describePreviousShutdown(previous *botState, pending *maintenance.PendingReboot, bootTime time.Time) string {
+	if previous == nil {
+		return "ℹ️ Первый запуск бота"
+	}
+
+	rebooted := !bootTime.IsZero() && bootTime.After(previous.LastSeen)
+	switch {
+	case pending != nil && rebooted:
+		return "✅ Предыдущее завершение: перезагрузка по запросу из бота"
+	case pending != nil:
+		return "⚠️ Запрошенная перезагрузка не была выполнена"
+	case rebooted && previous.CleanShutdown:
+		return "ℹ️ Предыдущее завершение: штатная перезагрузка сервера"
+	case rebooted:
+		return fmt.Sprintf("⚠️ Предыдущее завершение: неожиданная перезагрузка сервера (последняя активность %s)",
+			previous.LastSeen.Format("2006-01-02 15:04:05"))
+	case previous.CleanShutdown:
+		return "ℹ️ Предыдущее завершение: штатный перезапуск бота"
+	default:
+		return fmt.Sprintf("⚠️ Предыдущее завершение: аварийная остановка бота (последняя активность %s)",
+			previous.LastSeen.Format("2006-01-02 15:04:05"))
+	}
+}
+
+// runningContainers возвращает имена запущенных контейнеров
+func (r *StartupReporter) runningContainers() ([]string, error) {
+	containers, err := r.dockerService.ListContainers()
+	if err != nil {
+		return nil, err
+	}
+
+	running := make([]string, 0)
+	for _, container := range containers {
+		if container.Running() {
+			running = append(running, container.Name)
+		}
+	}
+	return running, nil
+}
+
+// send отправляет сообщение в чат
+func (r *StartupReporter) send(chatID int64, message string) {
+	msg := tgbotapi.NewMessage(chatID, message)
+	if _, err := r.bot.Send(msg); err != nil {
+		log.Printf("Report: Ошибка отправки отчета: %v", err)
+	}
+}
+
+// difference возвращает элементы a, отсутствующие в b
+func difference(a, b []string) []string {
+	present := make(map[string]bool, len(b))
+	for _, v := range b {
+		present[v] = true
+	}
+
+	var result []string
+	for _, v := range a {
+		if !present[v] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,221 @@
+package system
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// rotatedLogDir каталог, в котором ищутся ротированные журналы
+const rotatedLogDir = "/var/log"
+
+// rotatedLogPatterns шаблоны имен ротированных журналов (сжатые и пронумерованные копии)
+var rotatedLogPatterns = []string{"*.gz", "*.xz", "*.bz2", "*.zst", "*.[0-9]", "*.old"}
+
+// CleanupEstimate оценка места, освобождаемого очисткой
+type CleanupEstimate struct {
+	Reclaimable uint64
+	// Items удаляемые объекты (пакеты, файлы), отсортированные по убыванию значимости
+	Items []string
+}
+
+// FreeSpace возвращает суммарное свободное место на файловых системах, содержащих указанные пути
+// Пути одной файловой системы учитываются один раз, несуществующие пути пропускаются
+func (m *Monitor) FreeSpace(paths ...string) uint64 {
+	seen := make(map[uint64]bool)
+	var free uint64
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok || seen[uint64(stat.Dev)] {
+			continue
+		}
+		seen[uint64(stat.Dev)] = true
+
+		var fs syscall.Statfs_t
+		if err := syscall.Statfs(path, &fs); err != nil {
+			continue
+		}
+		free += fs.Bavail * uint64(fs.Bsize)
+	}
+	return free
+}
+
+// EstimateJournalVacuum оценивает место, освобождаемое сокращением журнала systemd до targetMB мегабайт
+func (m *Monitor) EstimateJournalVacuum(targetMB int) (*CleanupEstimate, error) {
+	output, err := runPrivileged("journalctl", "--disk-usage")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения размера журнала: %v", err)
+	}
+	usage, err := parseJournalUsage(output)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &CleanupEstimate{}
+	if target := uint64(targetMB) << 20; usage > target {
+		estimate.Reclaimable = usage - target
+	}
+	return estimate, nil
+}
+
+// VacuumJournal сокращает журнал systemd до targetMB мегабайт
+// Удаляются только архивные файлы журнала, поэтому итоговый размер может превышать заданный
+func (m *Monitor) VacuumJournal(targetMB int) error {
+	output, err := privilegedCommand("journalctl", fmt.Sprintf("--vacuum-size=%dM", targetMB)).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ошибка очистки журнала: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// EstimatePackageCache оценивает место, занятое кэшем пакетного менеджера
+func (m *Monitor) EstimatePackageCache() (*CleanupEstimate, error) {
+	if m.packageManager == nil {
+		return nil, fmt.Errorf("пакетный менеджер не обнаружен")
+	}
+	cacheDir := m.packageManager.CacheDir()
+
+	// Кэш обычно доступен только root, поэтому размер считается через du с повышенными правами
+	output, err := runPrivileged("du", "-sxk", cacheDir)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подсчета размера %s: %v", cacheDir, err)
+	}
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("не удалось разобрать вывод du")
+	}
+	kb, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось разобрать вывод du: %v", err)
+	}
+
+	return &CleanupEstimate{Reclaimable: kb << 10, Items: []string{cacheDir}}, nil
+}
+
+// CleanPackageCache очищает кэш пакетного менеджера
+func (m *Monitor) CleanPackageCache() error {
+	if m.packageManager == nil {
+		return fmt.Errorf("пакетный менеджер не обнаружен")
+	}
+	return m.packageManager.CleanCache()
+}
+
+// EstimateRotatedLogs находит ротированные журналы старше ageDays дней и подсчитывает их размер
+func (m *Monitor) EstimateRotatedLogs(ageDays int) (*CleanupEstimate, error) {
+	args := append(rotatedLogFindArgs(ageDays), "-exec", "du", "-k", "{}", "+")
+	output, err := runPrivileged("find", args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска ротированных журналов: %v", err)
+	}
+
+	type logFile struct {
+		path string
+		size uint64
+	}
+	var files []logFile
+	estimate := &CleanupEstimate{}
+	for _, line := range nonEmptyLines(output) {
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		kb, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		files = append(files, logFile{path: parts[1], size: kb << 10})
+		estimate.Reclaimable += kb << 10
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].size > files[j].size })
+	for _, file := range files {
+		estimate.Items = append(estimate.Items, file.path)
+	}
+	return estimate, nil
+}
+
+// RemoveRotatedLogs удаляет ротированные журналы старше ageDays дней
+func (m *Monitor) RemoveRotatedLogs(ageDays int) error {
+	args := append(rotatedLogFindArgs(ageDays), "-delete")
+	output, err := privilegedCommand("find", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ошибка удаления ротированных журналов: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// EstimateOldKernels находит неиспользуемые старые ядра и оценивает место, освобождаемое их удалением
+func (m *Monitor) EstimateOldKernels() (*CleanupEstimate, error) {
+	if m.packageManager == nil {
+		return nil, fmt.Errorf("пакетный менеджер не обнаружен")
+	}
+	return m.packageManager.OldKernels()
+}
+
+// RemoveOldKernels удаляет неиспользуемые старые ядра
+func (m *Monitor) RemoveOldKernels() error {
+	if m.packageManager == nil {
+		return fmt.Errorf("пакетный менеджер не обнаружен")
+	}
+	return m.packageManager.RemoveOldKernels()
+}
+
+// rotatedLogFindArgs возвращает аргументы find для поиска ротированных журналов старше ageDays дней
+func rotatedLogFindArgs(ageDays int) []string {
+	args := []string{rotatedLogDir, "-xdev", "-type", "f", "("}
+	for i, pattern := range rotatedLogPatterns {
+		if i > 0 {
+			args = append(args, "-o")
+		}
+		args = append(args, "-name", pattern)
+	}
+	return append(args, ")", "-mtime", "+"+strconv.Itoa(ageDays))
+}
+
+// parseJournalUsage извлекает размер журнала из вывода journalctl --disk-usage
+// Archived and active journals take up 1.2G in the file system.
+func parseJournalUsage(output string) (uint64, error) {
+	fields := strings.Fields(output)
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == "up" && i > 0 && fields[i-1] == "take" {
+			if size, ok := parseBinarySize(fields[i+1]); ok {
+				return size, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("не удалось разобрать размер журнала: %s", strings.TrimSpace(output))
+}
+
+// parseBinarySize разбирает размер с двоичным суффиксом (1.5G, 300M, 512K, 100B)
+func parseBinarySize(value string) (uint64, bool) {
+	value = strings.TrimSuffix(strings.TrimSuffix(value, "B"), "i")
+	multiplier := uint64(1)
+	if value != "" {
+		switch value[len(value)-1] {
+		case 'K', 'k':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		case 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			value = value[:len(value)-1]
+		}
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint64(number * float64(multiplier)), true
+}
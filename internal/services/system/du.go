@@ -0,0 +1,196 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// duTimeBudget ограничение времени подсчета занятого места
+const duTimeBudget = 20 * time.Second
+
+// duMinFileSize минимальный размер файла в килобайтах для списка крупнейших; мелкие файлы не выводятся из find
+const duMinFileSize = 1024
+
+// DirEntry каталог или файл с занятым местом
+type DirEntry struct {
+	// Path путь относительно каталога, для которого выполнялся подсчет
+	Path  string
+	Size  uint64
+	IsDir bool
+}
+
+// DirUsage занятое место в каталоге
+type DirUsage struct {
+	Path  string
+	Total uint64
+	// Dirs непосредственные подкаталоги, отсортированные по размеру
+	Dirs []DirEntry
+	// Files крупнейшие файлы во всем дереве каталога
+	Files []DirEntry
+	// Truncated означает, что подсчет прерван по времени и размеры занижены
+	Truncated bool
+	// Denied количество каталогов, которые не удалось прочитать
+	Denied int
+}
+
+// GetDirUsage подсчитывает место, занятое каталогом, его подкаталогами и крупнейшими файлами
+// Каталоги вроде /var/lib/docker доступны только root, поэтому du и find выполняются через sudo
+// Подсчет не выходит за пределы файловой системы каталога и ограничен по времени
+func (m *Monitor) GetDirUsage(path string, limit int) (*DirUsage, error) {
+	path = filepath.Clean(path)
+	if !filepath.IsAbs(path) {
+		return nil, fmt.Errorf("путь должен быть абсолютным")
+	}
+
+	// find без -L не переходит по символическим ссылкам и выводит путь, только если это каталог
+	output, err := privilegedCommand("find", path, "-maxdepth", "0", "-type", "d").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %s", path, strings.TrimSpace(string(output)))
+	}
+	if strings.TrimSpace(string(output)) == "" {
+		return nil, fmt.Errorf("%s не является каталогом", path)
+	}
+
+	usage := &DirUsage{Path: path}
+	var files []DirEntry
+	var filesErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		files, filesErr = largestFiles(path, limit)
+	}()
+
+	timeout := strconv.Itoa(int(duTimeBudget.Seconds()))
+	var stderr bytes.Buffer
+	cmd := privilegedCommand("timeout", timeout, "du", "-xk", "--max-depth=1", "--", path)
+	cmd.Stderr = &stderr
+	output, err = cmd.Output()
+	switch code := exitCode(err); {
+	case err == nil:
+	case code == 124:
+		// timeout завершил du: выведены только подсчитанные подкаталоги
+		usage.Truncated = true
+	case code == 1:
+		// du завершается с кодом 1, если часть каталогов не удалось прочитать
+	default:
+		return nil, fmt.Errorf("ошибка подсчета занятого места: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	usage.Denied = strings.Count(stderr.String(), "cannot read directory")
+
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	totalFound := false
+	var dirsTotal uint64
+	for _, line := range nonEmptyLines(string(output)) {
+		size, entry, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		kb, err := strconv.ParseUint(size, 10, 64)
+		if err != nil {
+			continue
+		}
+		if entry == path {
+			usage.Total, totalFound = kb<<10, true
+			continue
+		}
+		usage.Dirs = append(usage.Dirs, DirEntry{Path: strings.TrimPrefix(entry, prefix), Size: kb << 10, IsDir: true})
+		dirsTotal += kb << 10
+	}
+	if !totalFound {
+		usage.Total = dirsTotal
+	}
+
+	sort.Slice(usage.Dirs, func(i, j int) bool { return usage.Dirs[i].Size > usage.Dirs[j].Size })
+	if len(usage.Dirs) > limit {
+		usage.Dirs = usage.Dirs[:limit]
+	}
+
+	wg.Wait()
+	if filesErr != nil {
+		usage.Truncated = true
+	}
+	for i := range files {
+		files[i].Path = strings.TrimPrefix(files[i].Path, prefix)
+	}
+	usage.Files = files
+	return usage, nil
+}
+
+// largestFiles находит limit крупнейших файлов в дереве каталога, не выходя за пределы файловой системы
+// Ошибка означает, что поиск прерван по времени и список может быть неполным
+func largestFiles(path string, limit int) ([]DirEntry, error) {
+	timeout := strconv.Itoa(int(duTimeBudget.Seconds()))
+	cmd := privilegedCommand("timeout", timeout, "find", path, "-xdev", "-type", "f",
+		"-size", fmt.Sprintf("+%dk", duMinFileSize), "-printf", "%k\t%p\n")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var files []DirEntry
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		size, file, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok {
+			continue
+		}
+		kb, err := strconv.ParseUint(size, 10, 64)
+		if err != nil {
+			continue
+		}
+		files = addLargestFile(files, DirEntry{Path: file, Size: kb << 10}, limit)
+	}
+	// Недочитанный вывод заблокировал бы find на записи
+	io.Copy(io.Discard, stdout)
+
+	// find завершается с кодом 1, если часть каталогов не удалось прочитать
+	if err := cmd.Wait(); err != nil && exitCode(err) != 1 {
+		return files, err
+	}
+	return files, nil
+}
+
+// addLargestFile добавляет файл в список крупнейших, сохраняя сортировку по убыванию размера
+func addLargestFile(files []DirEntry, file DirEntry, limit int) []DirEntry {
+	if len(files) == limit && file.Size <= files[len(files)-1].Size {
+		return files
+	}
+
+	i := sort.Search(len(files), func(i int) bool { return files[i].Size < file.Size })
+	files = append(files, DirEntry{})
+	copy(files[i+1:], files[i:])
+	files[i] = file
+	if len(files) > limit {
+		files = files[:limit]
+	}
+	return files
+}
+
+// JournalDiskUsage возвращает место, занятое журналом systemd
+func (m *Monitor) JournalDiskUsage() (string, error) {
+	output, err := runPrivileged("journalctl", "--disk-usage")
+	if err != nil {
+		return "", fmt.Errorf("ошибка получения размера журнала: %v", err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// PackageCacheDir возвращает каталог кэша пакетного менеджера
+func (m *Monitor) PackageCacheDir() (string, error) {
+	if m.packageManager == nil {
+		return "", fmt.Errorf("пакетный менеджер не обнаружен")
+	}
+	return m.packageManager.CacheDir(), nil
+}
@@ -0,0 +1,148 @@
+package system
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// fail2banJailPattern допустимые имена jail: sshd, nginx-http-auth, postfix.sasl
+var fail2banJailPattern = regexp.MustCompile(`^[\w.-]+$`)
+
+// Fail2banJail состояние jail fail2ban
+type Fail2banJail struct {
+	Name string
+	// CurrentlyFailed и TotalFailed неудачные попытки в окне findtime и с запуска fail2ban
+	CurrentlyFailed int
+	TotalFailed     int
+	// CurrentlyBanned и TotalBanned заблокированные адреса сейчас и с запуска fail2ban
+	CurrentlyBanned int
+	TotalBanned     int
+	BannedIPs       []string
+}
+
+// Fail2banAvailable проверяет, установлен ли fail2ban
+func (m *Monitor) Fail2banAvailable() bool {
+	_, err := exec.LookPath("fail2ban-client")
+	return err == nil
+}
+
+// GetFail2banJails получает состояние всех jail fail2ban
+func (m *Monitor) GetFail2banJails() ([]*Fail2banJail, error) {
+	output, err := fail2banClient("status")
+	if err != nil {
+		return nil, err
+	}
+	names := parseFail2banJailList(output)
+
+	jails := make([]*Fail2banJail, 0, len(names))
+	for _, name := range names {
+		jail, err := m.GetFail2banJail(name)
+		if err != nil {
+			return nil, err
+		}
+		jails = append(jails, jail)
+	}
+	return jails, nil
+}
+
+// GetFail2banJail получает состояние jail и список заблокированных адресов
+func (m *Monitor) GetFail2banJail(name string) (*Fail2banJail, error) {
+	if !fail2banJailPattern.MatchString(name) {
+		return nil, fmt.Errorf("некорректное имя jail %q", name)
+	}
+	output, err := fail2banClient("status", name)
+	if err != nil {
+		return nil, err
+	}
+	return parseFail2banJailStatus(name, output), nil
+}
+
+// Fail2banBan блокирует адрес в jail
+func (m *Monitor) Fail2banBan(jail, ip string) error {
+	return fail2banSetIP(jail, "banip", ip)
+}
+
+// Fail2banUnban снимает блокировку адреса в jail
+func (m *Monitor) Fail2banUnban(jail, ip string) error {
+	return fail2banSetIP(jail, "unbanip", ip)
+}
+
+// fail2banSetIP выполняет команду jail над адресом после проверки аргументов
+func fail2banSetIP(jail, command, ip string) error {
+	if !fail2banJailPattern.MatchString(jail) {
+		return fmt.Errorf("некорректное имя jail %q", jail)
+	}
+	if net.ParseIP(ip) == nil {
+		return fmt.Errorf("некорректный IP адрес %q", ip)
+	}
+	_, err := fail2banClient("set", jail, command, ip)
+	return err
+}
+
+// fail2banClient выполняет fail2ban-client через sudo: сокет сервера доступен только root
+func fail2banClient(args ...string) (string, error) {
+	output, err := privilegedCommand("fail2ban-client", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("ошибка выполнения fail2ban-client: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// parseFail2banJailList разбирает список jail из вывода "fail2ban-client status"
+//
+//	`- Jail list:	nginx-http-auth, sshd
+func parseFail2banJailList(output string) []string {
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		_, value, ok := strings.Cut(line, "Jail list:")
+		if !ok {
+			continue
+		}
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// parseFail2banJailStatus разбирает вывод "fail2ban-client status <jail>"
+//
+//	|- Filter
+//	|  |- Currently failed:	1
+//	|  `- Total failed:	12
+//	`- Actions
+//	   |- Currently banned:	2
+//	   |- Total banned:	5
+//	   `- Banned IP list:	203.0.113.5 198.51.100.7
+func parseFail2banJailStatus(name, output string) *Fail2banJail {
+	jail := &Fail2banJail{Name: name}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimLeft(key, "|`- \t")
+		value = strings.TrimSpace(value)
+		number, _ := strconv.Atoi(value)
+
+		switch key {
+		case "Currently failed":
+			jail.CurrentlyFailed = number
+		case "Total failed":
+			jail.TotalFailed = number
+		case "Currently banned":
+			jail.CurrentlyBanned = number
+		case "Total banned":
+			jail.TotalBanned = number
+		case "Banned IP list":
+			jail.BannedIPs = strings.Fields(value)
+		}
+	}
+	return jail
+}
@@ -2,22 +2,52 @@ package system
 
 import (
 	"fmt"
+	"log"
 	"math"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/godbus/dbus/v5"
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/load"
 	"github.com/shirou/gopsutil/v3/mem"
 )
 
+// cpuSampleWindow интервал, за который измеряется загрузка CPU
+// Однократный снимок счетчиков не дает загрузку, поэтому значения считаются по разнице двух снимков
+const cpuSampleWindow = time.Second
+
 // CPUInfo структура для информации о CPU
 type CPUInfo struct {
-	Model     string
-	Cores     int
-	Frequency float64
-	Load      float64
+	Model         string
+	Cores         int
+	PhysicalCores int
+	Frequency     float64
+	// Load общая загрузка CPU в процентах за интервал измерения
+	Load float64
+	// PerCore загрузка каждого логического ядра в процентах
+	PerCore []float64
+	User    float64
+	System  float64
+	// IOWait время ожидания ввода-вывода в процентах
+	IOWait float64
+	// Steal время, отобранное гипервизором у виртуальной машины, в процентах
+	Steal float64
+	// ContextSwitches количество переключений контекста в секунду
+	ContextSwitches float64
+	LoadAverage     *LoadInfo
+	Uptime          time.Duration
+	BootTime        time.Time
+}
+
+// LoadInfo средняя загрузка системы за 1, 5 и 15 минут
+type LoadInfo struct {
+	Load1  float64
+	Load5  float64
+	Load15 float64
 }
 
 // MemoryInfo структура для информации о памяти
@@ -29,6 +59,12 @@ type MemoryInfo struct {
 	SwapTotal   float64
 	SwapUsed    float64
 	SwapPercent float64
+	// Точные значения в байтах для экспорта метрик
+	TotalBytes     uint64
+	UsedBytes      uint64
+	AvailableBytes uint64
+	SwapTotalBytes uint64
+	SwapUsedBytes  uint64
 }
 
 // DiskInfo структура для информации о диске
@@ -39,43 +75,165 @@ type DiskInfo struct {
 	Used        float64
 	Free        float64
 	UsedPercent float64
+	// Точные значения в байтах для экспорта метрик
+	TotalBytes uint64
+	UsedBytes  uint64
+	FreeBytes  uint64
+	// Inode; у некоторых файловых систем (btrfs) количество не ограничено и InodesTotal равно 0
+	InodesTotal       uint64
+	InodesUsed        uint64
+	InodesFree        uint64
+	InodesUsedPercent float64
 }
 
 // Monitor сервис мониторинга системы
-type Monitor struct{}
+type Monitor struct {
+	packageManager PackageManager
+	dataDir        string
+	upgradeJob     *UpgradeJob
+	mu             sync.Mutex
+}
 
 // NewMonitor создает новый монитор системы
-func NewMonitor() *Monitor {
-	return &Monitor{}
+// dataDir используется для хранения журналов обновлений
+func NewMonitor(dataDir string) *Monitor {
+	packageManager := DetectPackageManager()
+	if packageManager != nil {
+		log.Printf("Обнаружен пакетный менеджер: %s", packageManager.Name())
+	} else {
+		log.Println("Пакетный менеджер не обнаружен, обновления системы недоступны")
+	}
+
+	return &Monitor{
+		packageManager: packageManager,
+		dataDir:        dataDir,
+	}
 }
 
 // GetCPUInfo получает информацию о CPU
+// Загрузка измеряется за cpuSampleWindow, поэтому вызов блокируется на это время
 func (m *Monitor) GetCPUInfo() (*CPUInfo, error) {
 	// Получение информации о CPU
 	cpuInfo, err := cpu.Info()
 	if err != nil {
 		return nil, err
 	}
+	if len(cpuInfo) == 0 {
+		return nil, fmt.Errorf("информация о CPU недоступна")
+	}
 
-	// Получение загрузки CPU
-	cpuPercent, err := cpu.Percent(0, false)
+	// Первый снимок счетчиков времени CPU и переключений контекста
+	before, err := cpu.Times(true)
 	if err != nil {
 		return nil, err
 	}
+	miscBefore, miscErr := load.Misc()
+	started := time.Now()
 
-	if len(cpuInfo) == 0 {
-		return nil, fmt.Errorf("информация о CPU недоступна")
+	time.Sleep(cpuSampleWindow)
+
+	after, err := cpu.Times(true)
+	if err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(started).Seconds()
+
+	info := &CPUInfo{
+		Model:     cpuInfo[0].ModelName,
+		Cores:     len(after),
+		Frequency: cpuInfo[0].Mhz,
+	}
+	if physical, err := cpu.Counts(false); err == nil {
+		info.PhysicalCores = physical
 	}
 
-	info := cpuInfo[0]
-	load := cpuPercent[0]
+	// Суммарные значения по всем ядрам дают общую загрузку
+	var total cpu.TimesStat
+	for i := range after {
+		if i >= len(before) {
+			break
+		}
+		delta := cpuTimesDelta(before[i], after[i])
+		info.PerCore = append(info.PerCore, cpuBusyPercent(delta))
+		total = cpuTimesSum(total, delta)
+	}
 
-	return &CPUInfo{
-		Model:     info.ModelName,
-		Cores:     int(info.Cores),
-		Frequency: info.Mhz,
-		Load:      load,
-	}, nil
+	if all := cpuTimesTotal(total); all > 0 {
+		info.Load = cpuBusyPercent(total)
+		info.User = (total.User + total.Nice) / all * 100
+		info.System = (total.System + total.Irq + total.Softirq) / all * 100
+		info.IOWait = total.Iowait / all * 100
+		info.Steal = total.Steal / all * 100
+	}
+
+	if miscErr == nil {
+		if miscAfter, err := load.Misc(); err == nil && elapsed > 0 {
+			info.ContextSwitches = float64(miscAfter.Ctxt-miscBefore.Ctxt) / elapsed
+		}
+	}
+
+	if loadInfo, err := m.GetLoadAverage(); err == nil {
+		info.LoadAverage = loadInfo
+	}
+	if uptime, bootTime, err := m.GetUptime(); err == nil {
+		info.Uptime, info.BootTime = uptime, bootTime
+	}
+
+	return info, nil
+}
+
+// cpuTimesDelta возвращает разницу счетчиков времени CPU между двумя снимками
+func cpuTimesDelta(before, after cpu.TimesStat) cpu.TimesStat {
+	return cpu.TimesStat{
+		CPU:     after.CPU,
+		User:    math.Max(after.User-before.User, 0),
+		System:  math.Max(after.System-before.System, 0),
+		Idle:    math.Max(after.Idle-before.Idle, 0),
+		Nice:    math.Max(after.Nice-before.Nice, 0),
+		Iowait:  math.Max(after.Iowait-before.Iowait, 0),
+		Irq:     math.Max(after.Irq-before.Irq, 0),
+		Softirq: math.Max(after.Softirq-before.Softirq, 0),
+		Steal:   math.Max(after.Steal-before.Steal, 0),
+	}
+}
+
+// cpuTimesSum складывает счетчики времени CPU
+func cpuTimesSum(a, b cpu.TimesStat) cpu.TimesStat {
+	return cpu.TimesStat{
+		User:    a.User + b.User,
+		System:  a.System + b.System,
+		Idle:    a.Idle + b.Idle,
+		Nice:    a.Nice + b.Nice,
+		Iowait:  a.Iowait + b.Iowait,
+		Irq:     a.Irq + b.Irq,
+		Softirq: a.Softirq + b.Softirq,
+		Steal:   a.Steal + b.Steal,
+	}
+}
+
+// cpuTimesTotal возвращает полное время CPU
+// Время гостевых систем уже входит в user, поэтому не учитывается повторно
+func cpuTimesTotal(t cpu.TimesStat) float64 {
+	return t.User + t.System + t.Idle + t.Nice + t.Iowait + t.Irq + t.Softirq + t.Steal
+}
+
+// cpuBusyPercent возвращает долю времени, когда CPU был занят; ожидание ввода-вывода считается простоем
+func cpuBusyPercent(t cpu.TimesStat) float64 {
+	total := cpuTimesTotal(t)
+	if total <= 0 {
+		return 0
+	}
+	return (total - t.Idle - t.Iowait) / total * 100
+}
+
+// GetLoadAverage получает среднюю загрузку системы
+func (m *Monitor) GetLoadAverage() (*LoadInfo, error) {
+	avg, err := load.Avg()
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoadInfo{Load1: avg.Load1, Load5: avg.Load5, Load15: avg.Load15}, nil
 }
 
 // GetCPUInfoString получает информацию о CPU в виде строки (для совместимости)
@@ -85,10 +243,15 @@ func (m *Monitor) GetCPUInfoString() (string, error) {
 		return "Информация о CPU недоступна", err
 	}
 
-	return fmt.Sprintf(
-		"Модель: %s\nЯдер: %d\nЧастота: %.2f MHz\nЗагрузка: %.2f%%",
-		cpuInfo.Model, cpuInfo.Cores, cpuInfo.Frequency, cpuInfo.Load,
-	), nil
+	result := fmt.Sprintf(
+		"Модель: %s\nЯдер: %d\nЧастота: %.2f MHz\nЗагрузка: %.2f%% (iowait %.1f%%, steal %.1f%%)",
+		cpuInfo.Model, cpuInfo.Cores, cpuInfo.Frequency, cpuInfo.Load, cpuInfo.IOWait, cpuInfo.Steal,
+	)
+	if cpuInfo.LoadAverage != nil {
+		result += fmt.Sprintf("\nLoad average: %.2f / %.2f / %.2f",
+			cpuInfo.LoadAverage.Load1, cpuInfo.LoadAverage.Load5, cpuInfo.LoadAverage.Load15)
+	}
+	return result, nil
 }
 
 // GetMemoryInfo получает информацию о памяти
@@ -113,6 +276,12 @@ func (m *Monitor) GetMemoryInfo() (*MemoryInfo, error) {
 		SwapTotal:   bytesToGB(swapInfo.Total),
 		SwapUsed:    bytesToGB(swapInfo.Used),
 		SwapPercent: swapInfo.UsedPercent,
+
+		TotalBytes:     memInfo.Total,
+		UsedBytes:      memInfo.Used,
+		AvailableBytes: memInfo.Available,
+		SwapTotalBytes: swapInfo.Total,
+		SwapUsedBytes:  swapInfo.Used,
 	}, nil
 }
 
@@ -140,8 +309,8 @@ func (m *Monitor) GetDiskInfo() ([]*DiskInfo, error) {
 
 	var diskInfos []*DiskInfo
 	for _, partition := range partitions {
-		// Пропускаем временные файловые системы
-		if partition.Fstype == "tmpfs" || partition.Fstype == "devtmpfs" {
+		// Пропускаем временные файловые системы и образы snap-пакетов
+		if partition.Fstype == "tmpfs" || partition.Fstype == "devtmpfs" || partition.Fstype == "squashfs" {
 			continue
 		}
 
@@ -157,6 +326,14 @@ func (m *Monitor) GetDiskInfo() ([]*DiskInfo, error) {
 			Used:        bytesToGB(usage.Used),
 			Free:        bytesToGB(usage.Free),
 			UsedPercent: usage.UsedPercent,
+			TotalBytes:  usage.Total,
+			UsedBytes:   usage.Used,
+			FreeBytes:   usage.Free,
+
+			InodesTotal:       usage.InodesTotal,
+			InodesUsed:        usage.InodesUsed,
+			InodesFree:        usage.InodesFree,
+			InodesUsedPercent: usage.InodesUsedPercent,
 		})
 	}
 
@@ -177,10 +354,15 @@ func (m *Monitor) GetDiskInfoString() (string, error) {
 	result := ""
 	for _, diskInfo := range diskInfos {
 		result += fmt.Sprintf(
-			"%s (%s):\n  Всего: %.2f GB\n  Использовано: %.2f GB\n  Свободно: %.2f GB\n  Загрузка: %.2f%%\n\n",
+			"%s (%s):\n  Всего: %.2f GB\n  Использовано: %.2f GB\n  Свободно: %.2f GB\n  Загрузка: %.2f%%\n",
 			diskInfo.MountPoint, diskInfo.FileSystem,
 			diskInfo.Total, diskInfo.Used, diskInfo.Free, diskInfo.UsedPercent,
 		)
+		if diskInfo.InodesTotal > 0 {
+			result += fmt.Sprintf("  Inode: %d из %d (%.2f%%)\n",
+				diskInfo.InodesUsed, diskInfo.InodesTotal, diskInfo.InodesUsedPercent)
+		}
+		result += "\n"
 	}
 
 	return result, nil
@@ -193,40 +375,60 @@ func bytesToGB(bytes uint64) float64 {
 
 // Reboot перезагружает сервер
 func (m *Monitor) Reboot() error {
+	if m.UpgradeRunning() {
+		return errUpgradeRunning
+	}
 	cmd := exec.Command("sudo", "reboot")
 	return cmd.Run()
 }
 
 // Shutdown выключает сервер
 func (m *Monitor) Shutdown() error {
+	if m.UpgradeRunning() {
+		return errUpgradeRunning
+	}
 	cmd := exec.Command("sudo", "shutdown", "-h", "now")
 	return cmd.Run()
 }
 
+// PackageManagerName возвращает имя используемого пакетного менеджера
+func (m *Monitor) PackageManagerName() string {
+	if m.packageManager == nil {
+		return ""
+	}
+	return m.packageManager.Name()
+}
+
 // CheckUpdates проверяет доступные обновления системы
-func (m *Monitor) CheckUpdates() (string, error) {
-	// Выполняем команду для обновления списка пакетов
-	updateCmd := exec.Command("sudo", "apt", "update")
-	_, err := updateCmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("ошибка обновления списка пакетов: %v", err)
+func (m *Monitor) CheckUpdates() ([]UpgradablePackage, error) {
+	if m.packageManager == nil {
+		return nil, errNoPackageManager
 	}
 
-	// Выполняем команду для проверки доступных обновлений
-	cmd := exec.Command("sudo", "apt", "list", "--upgradable")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("ошибка проверки обновлений: %v", err)
+	// Обновляем метаданные репозиториев перед проверкой
+	if err := m.packageManager.Refresh(); err != nil {
+		return nil, err
 	}
 
-	return string(output), nil
+	return m.packageManager.ListUpgradable()
 }
 
-// UpgradeSystem обновляет систему
-func (m *Monitor) UpgradeSystem() error {
-	// Выполняем команду для обновления системы
-	cmd := exec.Command("sudo", "apt", "upgrade", "-y")
-	return cmd.Run()
+// CachedUpdates возвращает доступные обновления по уже загруженным метаданным репозиториев без их обновления
+func (m *Monitor) CachedUpdates() ([]UpgradablePackage, error) {
+	if m.packageManager == nil {
+		return nil, errNoPackageManager
+	}
+
+	return m.packageManager.ListUpgradable()
+}
+
+// PreviewUpgrade выполняет пробное обновление указанных пакетов или всей системы
+func (m *Monitor) PreviewUpgrade(packages ...string) (*UpgradePlan, error) {
+	if m.packageManager == nil {
+		return nil, errNoPackageManager
+	}
+
+	return m.packageManager.Simulate(packages)
 }
 
 // GetServices получает список запущенных systemd сервисов через D-Bus
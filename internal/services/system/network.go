@@ -0,0 +1,155 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// netSampleWindow интервал, за который измеряется скорость сетевых интерфейсов
+const netSampleWindow = time.Second
+
+// NetCounters счетчики трафика сетевого интерфейса с момента загрузки
+type NetCounters struct {
+	Name        string
+	BytesRecv   uint64
+	BytesSent   uint64
+	PacketsRecv uint64
+	PacketsSent uint64
+	ErrorsIn    uint64
+	ErrorsOut   uint64
+	DropsIn     uint64
+	DropsOut    uint64
+	// Physical интерфейс связан с устройством (сетевой картой, virtio), а не создан программно, как docker0, br-* и veth*
+	Physical bool
+}
+
+// NetInterface сетевой интерфейс с адресами, состоянием и текущей скоростью
+type NetInterface struct {
+	Name         string
+	HardwareAddr string
+	MTU          int
+	Addrs        []string
+	// State состояние канала из /sys/class/net/<интерфейс>/operstate (up, down, unknown)
+	State string
+	Up    bool
+	// RxRate и TxRate скорость приема и передачи в байтах в секунду
+	RxRate   float64
+	TxRate   float64
+	Counters NetCounters
+}
+
+// GetNetCounters получает счетчики трафика сетевых интерфейсов, кроме loopback
+func (m *Monitor) GetNetCounters() ([]NetCounters, error) {
+	stats, err := net.IOCounters(true)
+	if err != nil {
+		return nil, err
+	}
+
+	counters := make([]NetCounters, 0, len(stats))
+	for _, stat := range stats {
+		if stat.Name == "lo" {
+			continue
+		}
+		counters = append(counters, NetCounters{
+			Name:        stat.Name,
+			BytesRecv:   stat.BytesRecv,
+			BytesSent:   stat.BytesSent,
+			PacketsRecv: stat.PacketsRecv,
+			PacketsSent: stat.PacketsSent,
+			ErrorsIn:    stat.Errin,
+			ErrorsOut:   stat.Errout,
+			DropsIn:     stat.Dropin,
+			DropsOut:    stat.Dropout,
+			Physical:    physicalInterface(stat.Name),
+		})
+	}
+	return counters, nil
+}
+
+// GetNetInterfaces получает сетевые интерфейсы, кроме loopback, со скоростью за netSampleWindow
+// Вызов блокируется на время измерения скорости
+func (m *Monitor) GetNetInterfaces() ([]*NetInterface, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := m.GetNetCounters()
+	if err != nil {
+		return nil, err
+	}
+	started := time.Now()
+	time.Sleep(netSampleWindow)
+	after, err := m.GetNetCounters()
+	if err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(started).Seconds()
+
+	previous := make(map[string]NetCounters, len(before))
+	for _, counter := range before {
+		previous[counter.Name] = counter
+	}
+	current := make(map[string]NetCounters, len(after))
+	for _, counter := range after {
+		current[counter.Name] = counter
+	}
+
+	result := make([]*NetInterface, 0, len(interfaces))
+	for _, iface := range interfaces {
+		if hasFlag(iface.Flags, "loopback") {
+			continue
+		}
+
+		info := &NetInterface{
+			Name:         iface.Name,
+			HardwareAddr: iface.HardwareAddr,
+			MTU:          iface.MTU,
+			Up:           hasFlag(iface.Flags, "up"),
+			State:        linkState(iface.Name),
+			Counters:     current[iface.Name],
+		}
+		for _, addr := range iface.Addrs {
+			info.Addrs = append(info.Addrs, addr.Addr)
+		}
+
+		// Счетчики сбрасываются при перезапуске интерфейса, тогда скорость не определяется
+		if last, ok := previous[iface.Name]; ok && elapsed > 0 &&
+			info.Counters.BytesRecv >= last.BytesRecv && info.Counters.BytesSent >= last.BytesSent {
+			info.RxRate = float64(info.Counters.BytesRecv-last.BytesRecv) / elapsed
+			info.TxRate = float64(info.Counters.BytesSent-last.BytesSent) / elapsed
+		}
+
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// hasFlag проверяет наличие флага интерфейса
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// physicalInterface проверяет, что у интерфейса есть устройство в /sys/class/net/<интерфейс>/device
+func physicalInterface(name string) bool {
+	_, err := os.Stat(filepath.Join(hostSysPath(), "class", "net", name, "device"))
+	return err == nil
+}
+
+// linkState читает состояние канала интерфейса; учитывает HOST_SYS при запуске в контейнере
+func linkState(name string) string {
+	data, err := os.ReadFile(filepath.Join(hostSysPath(), "class", "net", name, "operstate"))
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(data))
+}
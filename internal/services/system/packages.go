@@ -0,0 +1,123 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// UpgradablePackage структура пакета, для которого доступно обновление
+type UpgradablePackage struct {
+	Name      string
+	Current   string
+	Candidate string
+	Repo      string
+	Security  bool
+}
+
+// PackageChange изменение пакета в плане обновления
+type PackageChange struct {
+	Name    string
+	Version string
+}
+
+// UpgradePlan план обновления, полученный пробным запуском
+type UpgradePlan struct {
+	Upgrade []PackageChange
+	Install []PackageChange
+	Remove  []PackageChange
+}
+
+// Empty проверяет, что план не содержит изменений
+func (p *UpgradePlan) Empty() bool {
+	return len(p.Upgrade) == 0 && len(p.Install) == 0 && len(p.Remove) == 0
+}
+
+// PackageManager абстракция пакетного менеджера дистрибутива
+type PackageManager interface {
+	// Name возвращает имя пакетного менеджера
+	Name() string
+	// Refresh обновляет метаданные репозиториев
+	Refresh() error
+	// ListUpgradable возвращает список пакетов, доступных для обновления
+	ListUpgradable() ([]UpgradablePackage, error)
+	// Simulate выполняет пробное обновление и возвращает план изменений.
+	// Пустой список пакетов означает обновление всей системы
+	Simulate(packages []string) (*UpgradePlan, error)
+	// UpgradeCommand возвращает команду обновления указанных пакетов или всей системы, если список пуст
+	UpgradeCommand(packages []string) *exec.Cmd
+	// ParseProgress извлекает этап и обрабатываемый пакет из строки вывода обновления
+	ParseProgress(line string) (phase, pkg string)
+	// HeldBack возвращает пакеты, обновление которых было отложено
+	HeldBack(output string) []string
+	// CacheDir возвращает каталог кэша загруженных пакетов
+	CacheDir() string
+	// CleanCache удаляет загруженные пакеты из кэша
+	CleanCache() error
+	// OldKernels возвращает пакеты неиспользуемых старых ядер и место, освобождаемое их удалением
+	OldKernels() (*CleanupEstimate, error)
+	// RemoveOldKernels удаляет неиспользуемые старые ядра
+	RemoveOldKernels() error
+}
+
+// DetectPackageManager определяет пакетный менеджер, установленный в системе
+func DetectPackageManager() PackageManager {
+	switch {
+	case commandExists("apt-get"):
+		return &aptManager{}
+	case commandExists("dnf"):
+		return &dnfManager{binary: "dnf"}
+	case commandExists("yum"):
+		return &dnfManager{binary: "yum"}
+	case commandExists("apk"):
+		return &apkManager{}
+	case commandExists("pacman"):
+		return &pacmanManager{}
+	}
+	return nil
+}
+
+// commandExists проверяет наличие исполняемого файла в PATH
+func commandExists(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// runPrivileged выполняет команду через sudo и возвращает её stdout
+func runPrivileged(name string, args ...string) (string, error) {
+	output, err := privilegedCommand(name, args...).Output()
+	return string(output), err
+}
+
+// privilegedCommand создает команду, выполняемую через sudo
+func privilegedCommand(name string, args ...string) *exec.Cmd {
+	return exec.Command("sudo", append([]string{name}, args...)...)
+}
+
+// exitCode возвращает код завершения команды или -1, если команда не была запущена
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// nonEmptyLines разбивает вывод команды на непустые строки
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// errNoPackageManager возвращается, если пакетный менеджер не определен
+var errNoPackageManager = fmt.Errorf("поддерживаемый пакетный менеджер не найден")
+
+// errUpgradeRunning возвращается при попытке перезагрузки или выключения во время обновления:
+// прерванная установка пакетов может оставить систему в неконсистентном состоянии
+var errUpgradeRunning = fmt.Errorf("выполняется обновление системы, дождитесь его завершения")
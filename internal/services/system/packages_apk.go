@@ -0,0 +1,148 @@
+package system
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// apkManager пакетный менеджер Alpine Linux
+type apkManager struct{}
+
+// Name возвращает имя пакетного менеджера
+func (a *apkManager) Name() string {
+	return "apk"
+}
+
+// CacheDir возвращает каталог кэша загруженных пакетов
+func (a *apkManager) CacheDir() string {
+	return "/var/cache/apk"
+}
+
+// CleanCache удаляет из кэша пакеты, которые больше не нужны
+func (a *apkManager) CleanCache() error {
+	output, err := privilegedCommand("apk", "cache", "clean").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ошибка очистки кэша пакетов: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// OldKernels возвращает пустой результат: apk обновляет пакет ядра на месте и не хранит старые версии
+func (a *apkManager) OldKernels() (*CleanupEstimate, error) {
+	return &CleanupEstimate{}, nil
+}
+
+// RemoveOldKernels ничего не делает, так как старые ядра apk не сохраняет
+func (a *apkManager) RemoveOldKernels() error {
+	return nil
+}
+
+// Refresh обновляет индексы репозиториев
+func (a *apkManager) Refresh() error {
+	if _, err := runPrivileged("apk", "update", "-q"); err != nil {
+		return fmt.Errorf("ошибка обновления индексов репозиториев: %v", err)
+	}
+	return nil
+}
+
+// ListUpgradable возвращает список пакетов, доступных для обновления
+func (a *apkManager) ListUpgradable() ([]UpgradablePackage, error) {
+	output, err := runPrivileged("apk", "version", "-l", "<")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки обновлений: %v", err)
+	}
+
+	return parseApkVersion(output), nil
+}
+
+// Simulate выполняет пробное обновление
+func (a *apkManager) Simulate(packages []string) (*UpgradePlan, error) {
+	args := append([]string{"upgrade", "--simulate"}, packages...)
+	output, err := runPrivileged("apk", args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка пробного обновления: %v", err)
+	}
+
+	return parseApkTransaction(output), nil
+}
+
+// UpgradeCommand возвращает команду обновления указанных пакетов или всей системы
+func (a *apkManager) UpgradeCommand(packages []string) *exec.Cmd {
+	return privilegedCommand("apk", append([]string{"upgrade"}, packages...)...)
+}
+
+// ParseProgress извлекает этап и пакет из строки вывода apk
+func (a *apkManager) ParseProgress(line string) (string, string) {
+	// (1/3) Upgrading busybox (1.36.1-r0 -> 1.36.1-r2)
+	fields := strings.Fields(line)
+	if len(fields) >= 3 && strings.HasPrefix(fields[0], "(") {
+		return fields[1], fields[2]
+	}
+	if len(fields) >= 2 && fields[0] == "fetch" {
+		return "Загрузка индексов", fields[1]
+	}
+	return "", ""
+}
+
+// HeldBack apk не откладывает обновление отдельных пакетов
+func (a *apkManager) HeldBack(output string) []string {
+	return nil
+}
+
+// parseApkVersion парсит вывод apk version -l '<'
+// Формат строки: name-version-rN < candidate
+func parseApkVersion(output string) []UpgradablePackage {
+	var packages []UpgradablePackage
+
+	for _, line := range nonEmptyLines(output) {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != "<" {
+			continue
+		}
+
+		name, current := splitApkNameVersion(fields[0])
+		packages = append(packages, UpgradablePackage{
+			Name:      name,
+			Current:   current,
+			Candidate: fields[2],
+		})
+	}
+
+	return packages
+}
+
+// splitApkNameVersion разделяет строку вида name-1.2.3-r0 на имя и версию
+func splitApkNameVersion(s string) (string, string) {
+	parts := strings.Split(s, "-")
+	if len(parts) < 3 {
+		return s, ""
+	}
+	return strings.Join(parts[:len(parts)-2], "-"), strings.Join(parts[len(parts)-2:], "-")
+}
+
+// parseApkTransaction парсит вывод apk upgrade
+// Формат строк: "(1/3) Upgrading name (current -> candidate)", "(2/3) Installing name (version)", "(3/3) Purging name (version)"
+func parseApkTransaction(output string) *UpgradePlan {
+	plan := &UpgradePlan{}
+
+	for _, line := range nonEmptyLines(output) {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasPrefix(fields[0], "(") {
+			continue
+		}
+
+		version := strings.Trim(fields[len(fields)-1], "()")
+		change := PackageChange{Name: fields[2], Version: version}
+		switch fields[1] {
+		case "Upgrading", "Downgrading", "Replacing":
+			plan.Upgrade = append(plan.Upgrade, change)
+		case "Installing":
+			plan.Install = append(plan.Install, change)
+		case "Purging", "Removing":
+			plan.Remove = append(plan.Remove, change)
+		}
+	}
+
+	return plan
+}
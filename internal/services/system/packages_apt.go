@@ -0,0 +1,273 @@
+package system
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// aptManager пакетный менеджер Debian/Ubuntu
+type aptManager struct{}
+
+// Name возвращает имя пакетного менеджера
+func (a *aptManager) Name() string {
+	return "apt"
+}
+
+// CacheDir возвращает каталог кэша загруженных пакетов
+func (a *aptManager) CacheDir() string {
+	return "/var/cache/apt/archives"
+}
+
+// CleanCache удаляет загруженные пакеты из кэша
+func (a *aptManager) CleanCache() error {
+	output, err := aptCommand("clean").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ошибка очистки кэша пакетов: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// OldKernels возвращает пакеты старых ядер, которые apt пометил как ненужные
+// Работающее и последнее установленное ядро apt к удалению не помечает
+func (a *aptManager) OldKernels() (*CleanupEstimate, error) {
+	packages, err := a.oldKernelPackages()
+	if err != nil {
+		return nil, err
+	}
+	if len(packages) == 0 {
+		return &CleanupEstimate{}, nil
+	}
+
+	// С --assume-no транзакция не выполняется, а команда завершается с кодом 1
+	output, err := aptCommand(append([]string{"purge", "--assume-no"}, packages...)...).Output()
+	if err != nil && exitCode(err) != 1 {
+		return nil, fmt.Errorf("ошибка оценки удаления ядер: %v", err)
+	}
+
+	return &CleanupEstimate{Reclaimable: parseAptFreedSpace(string(output)), Items: packages}, nil
+}
+
+// RemoveOldKernels удаляет старые ядра, помеченные apt как ненужные
+func (a *aptManager) RemoveOldKernels() error {
+	packages, err := a.oldKernelPackages()
+	if err != nil || len(packages) == 0 {
+		return err
+	}
+
+	output, err := aptCommand(append([]string{"purge", "-y"}, packages...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ошибка удаления ядер: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// oldKernelPackages возвращает пакеты ядер из пробного выполнения apt-get autoremove
+func (a *aptManager) oldKernelPackages() ([]string, error) {
+	output, err := aptCommand("-s", "autoremove", "--purge").Output()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска старых ядер: %v", err)
+	}
+
+	var packages []string
+	for _, change := range parseAptSimulation(string(output)).Remove {
+		for _, prefix := range aptKernelPrefixes {
+			if strings.HasPrefix(change.Name, prefix) {
+				packages = append(packages, change.Name)
+				break
+			}
+		}
+	}
+	return packages, nil
+}
+
+// Refresh обновляет список пакетов
+func (a *aptManager) Refresh() error {
+	if _, err := runPrivileged("apt-get", "update"); err != nil {
+		return fmt.Errorf("ошибка обновления списка пакетов: %v", err)
+	}
+	return nil
+}
+
+// ListUpgradable возвращает список пакетов, доступных для обновления
+func (a *aptManager) ListUpgradable() ([]UpgradablePackage, error) {
+	// Текущая версия извлекается из английского "upgradable from:"
+	output, err := runPrivileged("env", "LC_ALL=C", "apt", "list", "--upgradable")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки обновлений: %v", err)
+	}
+
+	return parseAptUpgradable(output), nil
+}
+
+// Simulate выполняет пробное обновление
+func (a *aptManager) Simulate(packages []string) (*UpgradePlan, error) {
+	output, err := aptCommand(append([]string{"-s"}, a.upgradeArgs(packages)...)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка пробного обновления: %v", err)
+	}
+
+	return parseAptSimulation(string(output)), nil
+}
+
+// UpgradeCommand возвращает команду обновления указанных пакетов или всей системы
+func (a *aptManager) UpgradeCommand(packages []string) *exec.Cmd {
+	// При конфликте конфигурационных файлов сохраняем локальные изменения без запроса
+	args := []string{"-y", "-o", "Dpkg::Options::=--force-confdef", "-o", "Dpkg::Options::=--force-confold"}
+	return aptCommand(append(args, a.upgradeArgs(packages)...)...)
+}
+
+// aptCommand создает команду apt-get с английским выводом и без интерактивных запросов
+// sudo сохраняет LANG и LC_*, а разбор плана, прогресса и отложенных пакетов рассчитан на английские сообщения
+func aptCommand(args ...string) *exec.Cmd {
+	return privilegedCommand("env", append([]string{"LC_ALL=C", "DEBIAN_FRONTEND=noninteractive", "apt-get"}, args...)...)
+}
+
+// ParseProgress извлекает этап и пакет из строки вывода apt-get
+func (a *aptManager) ParseProgress(line string) (string, string) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return "", ""
+	}
+
+	switch {
+	case strings.HasPrefix(fields[0], "Get:") && len(fields) > 4:
+		// Get:1 http://archive.ubuntu.com/ubuntu jammy-updates/main amd64 openssl amd64 3.0.2 [1183 kB]
+		return "Загрузка пакетов", fields[4]
+	case fields[0] == "Unpacking":
+		return "Распаковка", fields[1]
+	case fields[0] == "Setting" && fields[1] == "up" && len(fields) > 2:
+		return "Настройка", fields[2]
+	case fields[0] == "Removing":
+		return "Удаление", fields[1]
+	case fields[0] == "Processing" && strings.HasPrefix(line, "Processing triggers for") && len(fields) > 3:
+		return "Обработка триггеров", fields[3]
+	}
+	return "", ""
+}
+
+// HeldBack возвращает пакеты из секции "The following packages have been kept back"
+func (a *aptManager) HeldBack(output string) []string {
+	var packages []string
+	inSection := false
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "The following packages have been kept back") {
+			inSection = true
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		// Пакеты секции перечислены в строках с отступом
+		if !strings.HasPrefix(line, " ") {
+			break
+		}
+		packages = append(packages, strings.Fields(line)...)
+	}
+
+	return packages
+}
+
+// upgradeArgs формирует аргументы apt-get для обновления
+func (a *aptManager) upgradeArgs(packages []string) []string {
+	if len(packages) == 0 {
+		return []string{"upgrade"}
+	}
+	// --only-upgrade не позволяет установить пакеты, которых нет в системе
+	return append([]string{"install", "--only-upgrade"}, packages...)
+}
+
+// parseAptUpgradable парсит вывод apt list --upgradable
+// Формат строки: name/origin1,origin2 candidate arch [upgradable from: current]
+func parseAptUpgradable(output string) []UpgradablePackage {
+	var packages []UpgradablePackage
+
+	for _, line := range nonEmptyLines(output) {
+		if strings.HasPrefix(line, "Listing") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.Contains(fields[0], "/") {
+			continue
+		}
+
+		nameAndOrigins := strings.SplitN(fields[0], "/", 2)
+		pkg := UpgradablePackage{
+			Name:      nameAndOrigins[0],
+			Candidate: fields[1],
+			Repo:      nameAndOrigins[1],
+			Security:  strings.Contains(nameAndOrigins[1], "-security"),
+		}
+
+		// Текущая версия указана в конце строки после "from:"
+		if idx := strings.Index(line, "from: "); idx != -1 {
+			pkg.Current = strings.TrimSuffix(strings.TrimSpace(line[idx+len("from: "):]), "]")
+		}
+
+		packages = append(packages, pkg)
+	}
+
+	return packages
+}
+
+// parseAptSimulation парсит вывод apt-get -s
+// Формат строк: "Inst name [current] (candidate ...)", "Inst name (candidate ...)", "Remv name [current]"
+func parseAptSimulation(output string) *UpgradePlan {
+	plan := &UpgradePlan{}
+
+	for _, line := range nonEmptyLines(output) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "Inst":
+			if len(fields) > 2 && strings.HasPrefix(fields[2], "[") {
+				plan.Upgrade = append(plan.Upgrade, PackageChange{Name: fields[1], Version: aptSimulationVersion(fields[3:])})
+			} else {
+				plan.Install = append(plan.Install, PackageChange{Name: fields[1], Version: aptSimulationVersion(fields[2:])})
+			}
+		case "Remv":
+			plan.Remove = append(plan.Remove, PackageChange{Name: fields[1], Version: strings.Trim(strings.Join(fields[2:], " "), "[]")})
+		}
+	}
+
+	return plan
+}
+
+// aptSimulationVersion извлекает версию из фрагмента "(version origin [arch])"
+func aptSimulationVersion(fields []string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(fields[0], "(")
+}
+
+// aptKernelPrefixes префиксы пакетов ядра Debian/Ubuntu
+var aptKernelPrefixes = []string{"linux-image-", "linux-headers-", "linux-modules-"}
+
+// parseAptFreedSpace извлекает освобождаемое место из вывода apt-get
+// After this operation, 1,046 MB disk space will be freed.
+func parseAptFreedSpace(output string) uint64 {
+	for _, line := range nonEmptyLines(output) {
+		if !strings.HasPrefix(line, "After this operation,") || !strings.Contains(line, "freed") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "After this operation,"))
+		if len(fields) < 2 {
+			return 0
+		}
+		number, err := strconv.ParseFloat(strings.ReplaceAll(fields[0], ",", ""), 64)
+		if err != nil {
+			return 0
+		}
+		// apt использует десятичные единицы
+		multiplier := map[string]float64{"B": 1, "kB": 1e3, "MB": 1e6, "GB": 1e9}[fields[1]]
+		return uint64(number * multiplier)
+	}
+	return 0
+}
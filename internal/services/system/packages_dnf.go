@@ -0,0 +1,268 @@
+package system
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// dnfManager пакетный менеджер RHEL/Fedora (dnf или yum)
+type dnfManager struct {
+	binary string
+}
+
+// Name возвращает имя пакетного менеджера
+func (d *dnfManager) Name() string {
+	return d.binary
+}
+
+// CacheDir возвращает каталог кэша загруженных пакетов
+func (d *dnfManager) CacheDir() string {
+	return "/var/cache/" + d.binary
+}
+
+// CleanCache удаляет загруженные пакеты из кэша
+func (d *dnfManager) CleanCache() error {
+	output, err := privilegedCommand(d.binary, "clean", "packages").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ошибка очистки кэша пакетов: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// OldKernels возвращает старые версии ядер и других пакетов, устанавливаемых параллельно (installonly)
+func (d *dnfManager) OldKernels() (*CleanupEstimate, error) {
+	// С --assumeno транзакция не выполняется, а команда завершается с кодом 1;
+	// код 1 также означает отсутствие старых версий
+	output, err := runPrivileged(d.binary, "remove", "--oldinstallonly", "--assumeno")
+	if err != nil && exitCode(err) != 1 {
+		return nil, fmt.Errorf("ошибка поиска старых ядер: %v", err)
+	}
+
+	estimate := &CleanupEstimate{Reclaimable: parseDnfFreedSpace(output)}
+	for _, change := range parseDnfTransaction(output).Remove {
+		estimate.Items = append(estimate.Items, change.Name+"-"+change.Version)
+	}
+	return estimate, nil
+}
+
+// RemoveOldKernels удаляет старые версии installonly пакетов, сохраняя работающее и последнее ядро
+func (d *dnfManager) RemoveOldKernels() error {
+	estimate, err := d.OldKernels()
+	if err != nil || len(estimate.Items) == 0 {
+		return err
+	}
+
+	output, err := privilegedCommand(d.binary, "remove", "-y", "--oldinstallonly").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ошибка удаления ядер: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Refresh обновляет кэш метаданных репозиториев
+func (d *dnfManager) Refresh() error {
+	if _, err := runPrivileged(d.binary, "makecache", "-q"); err != nil {
+		return fmt.Errorf("ошибка обновления метаданных репозиториев: %v", err)
+	}
+	return nil
+}
+
+// ListUpgradable возвращает список пакетов, доступных для обновления
+func (d *dnfManager) ListUpgradable() ([]UpgradablePackage, error) {
+	output, err := d.checkUpdate()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки обновлений: %v", err)
+	}
+	packages := parseDnfCheckUpdate(output)
+	if len(packages) == 0 {
+		return packages, nil
+	}
+
+	// Отмечаем обновления безопасности
+	securityOutput, err := d.checkUpdate("--security")
+	if err == nil {
+		security := make(map[string]bool)
+		for _, pkg := range parseDnfCheckUpdate(securityOutput) {
+			security[pkg.Name] = true
+		}
+		for i := range packages {
+			packages[i].Security = security[packages[i].Name]
+		}
+	}
+
+	// Получаем установленные версии пакетов
+	names := make([]string, 0, len(packages))
+	for _, pkg := range packages {
+		names = append(names, pkg.Name)
+	}
+	args := append([]string{"-q", "--qf", "%{NAME} %{VERSION}-%{RELEASE}\n"}, names...)
+	rpmOutput, _ := runPrivileged("rpm", args...)
+	installed := make(map[string]string)
+	for _, line := range nonEmptyLines(rpmOutput) {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			installed[fields[0]] = fields[1]
+		}
+	}
+	for i := range packages {
+		packages[i].Current = installed[packages[i].Name]
+	}
+
+	return packages, nil
+}
+
+// Simulate выполняет пробное обновление
+func (d *dnfManager) Simulate(packages []string) (*UpgradePlan, error) {
+	// С --assumeno транзакция не выполняется, а команда завершается с кодом 1
+	args := append([]string{"upgrade", "--assumeno"}, packages...)
+	output, err := runPrivileged(d.binary, args...)
+	if err != nil && exitCode(err) != 1 {
+		return nil, fmt.Errorf("ошибка пробного обновления: %v", err)
+	}
+
+	return parseDnfTransaction(output), nil
+}
+
+// UpgradeCommand возвращает команду обновления указанных пакетов или всей системы
+func (d *dnfManager) UpgradeCommand(packages []string) *exec.Cmd {
+	return privilegedCommand(d.binary, append([]string{"upgrade", "-y"}, packages...)...)
+}
+
+// ParseProgress извлекает этап и пакет из строки вывода dnf/yum
+func (d *dnfManager) ParseProgress(line string) (string, string) {
+	trimmed := strings.TrimSpace(line)
+
+	// (1/5): openssl-libs-3.0.7-25.el9.x86_64.rpm   1.2 MB/s | 2.1 MB  00:01
+	if strings.HasPrefix(trimmed, "(") {
+		if idx := strings.Index(trimmed, "): "); idx != -1 {
+			fields := strings.Fields(trimmed[idx+3:])
+			if len(fields) > 0 {
+				return "Загрузка пакетов", fields[0]
+			}
+		}
+	}
+
+	//   Upgrading        : openssl-libs-1:3.0.7-25.el9.x86_64        1/10
+	parts := strings.SplitN(trimmed, " : ", 2)
+	if len(parts) == 2 {
+		fields := strings.Fields(parts[1])
+		if len(fields) > 0 {
+			return strings.TrimSpace(parts[0]), fields[0]
+		}
+	}
+	return "", ""
+}
+
+// HeldBack возвращает пакеты, пропущенные из-за конфликтов или зависимостей
+func (d *dnfManager) HeldBack(output string) []string {
+	var packages []string
+	inSection := false
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "Skipping packages") {
+			inSection = true
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			inSection = false
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) > 0 {
+			packages = append(packages, fields[0])
+		}
+	}
+
+	return packages
+}
+
+// checkUpdate выполняет check-update; код 100 означает наличие обновлений
+func (d *dnfManager) checkUpdate(extraArgs ...string) (string, error) {
+	args := append([]string{"check-update", "-q"}, extraArgs...)
+	output, err := runPrivileged(d.binary, args...)
+	if err != nil && exitCode(err) != 100 {
+		return "", err
+	}
+	return output, nil
+}
+
+// parseDnfCheckUpdate парсит вывод dnf check-update
+// Формат строки: name.arch candidate repo; длинные имена переносятся на следующую строку
+func parseDnfCheckUpdate(output string) []UpgradablePackage {
+	var packages []UpgradablePackage
+	var pending []string
+
+	for _, line := range nonEmptyLines(output) {
+		// Секция устаревающих пакетов не относится к обновлениям
+		if strings.HasPrefix(line, "Obsoleting") || strings.HasPrefix(line, "Security:") {
+			break
+		}
+
+		fields := append(pending, strings.Fields(line)...)
+		if len(fields) < 3 {
+			pending = fields
+			continue
+		}
+		pending = nil
+
+		name := fields[0]
+		if idx := strings.LastIndex(name, "."); idx != -1 {
+			name = name[:idx]
+		}
+
+		packages = append(packages, UpgradablePackage{
+			Name:      name,
+			Candidate: fields[1],
+			Repo:      fields[2],
+		})
+	}
+
+	return packages
+}
+
+// parseDnfTransaction парсит таблицу транзакции dnf/yum
+// Секции "Upgrading:", "Installing:", "Removing:" содержат строки вида " name arch version repo size"
+func parseDnfTransaction(output string) *UpgradePlan {
+	plan := &UpgradePlan{}
+	var section *[]PackageChange
+
+	for _, line := range nonEmptyLines(output) {
+		if !strings.HasPrefix(line, " ") {
+			switch {
+			case strings.HasPrefix(line, "Upgrad"), strings.HasPrefix(line, "Updat"):
+				section = &plan.Upgrade
+			case strings.HasPrefix(line, "Install"):
+				section = &plan.Install
+			case strings.HasPrefix(line, "Remov"), strings.HasPrefix(line, "Eras"):
+				section = &plan.Remove
+			default:
+				section = nil
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if section == nil || len(fields) < 4 {
+			continue
+		}
+		*section = append(*section, PackageChange{Name: fields[0], Version: fields[2]})
+	}
+
+	return plan
+}
+
+// parseDnfFreedSpace извлекает освобождаемое место из вывода dnf/yum
+// Freed space: 312 M
+func parseDnfFreedSpace(output string) uint64 {
+	for _, line := range nonEmptyLines(output) {
+		if !strings.HasPrefix(line, "Freed space:") {
+			continue
+		}
+		size, _ := parseBinarySize(strings.ReplaceAll(strings.TrimPrefix(line, "Freed space:"), " ", ""))
+		return size
+	}
+	return 0
+}
@@ -0,0 +1,199 @@
+package system
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// pacmanManager пакетный менеджер Arch Linux
+type pacmanManager struct{}
+
+// Name возвращает имя пакетного менеджера
+func (p *pacmanManager) Name() string {
+	return "pacman"
+}
+
+// CacheDir возвращает каталог кэша загруженных пакетов
+func (p *pacmanManager) CacheDir() string {
+	return "/var/cache/pacman/pkg"
+}
+
+// CleanCache удаляет из кэша пакеты, которые не установлены в системе
+// Версии установленных пакетов сохраняются, поэтому освобождается меньше места, чем занимает кэш
+func (p *pacmanManager) CleanCache() error {
+	output, err := privilegedCommand("pacman", "-Sc", "--noconfirm").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ошибка очистки кэша пакетов: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// OldKernels возвращает пустой результат: pacman обновляет пакет ядра на месте и не хранит старые версии
+func (p *pacmanManager) OldKernels() (*CleanupEstimate, error) {
+	return &CleanupEstimate{}, nil
+}
+
+// RemoveOldKernels ничего не делает, так как старые ядра pacman не сохраняет
+func (p *pacmanManager) RemoveOldKernels() error {
+	return nil
+}
+
+// pacmanSyncDBPath копия базы пакетов для проверки обновлений, как у checkupdates
+// Синхронизация системной базы без обновления пакетов (pacman -Sy) приводит к частичному обновлению
+// Каталог находится в /var/lib, куда может писать только root: в общедоступном каталоге
+// другой пользователь мог бы заранее создать его или подложить символическую ссылку
+const pacmanSyncDBPath = "/var/lib/tgbot-pacman-db"
+
+// pacmanLocalDBPath база установленных пакетов
+const pacmanLocalDBPath = "/var/lib/pacman/local"
+
+// Refresh синхронизирует отдельную копию базы пакетов, не затрагивая системную
+func (p *pacmanManager) Refresh() error {
+	if output, err := privilegedCommand("mkdir", "-p", "-m", "0755", pacmanSyncDBPath).CombinedOutput(); err != nil {
+		return fmt.Errorf("ошибка создания копии базы пакетов: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	if err := checkRootOwnedDir(pacmanSyncDBPath); err != nil {
+		return err
+	}
+	// Установленные пакеты берутся из системной базы
+	if output, err := privilegedCommand("ln", "-sfn", pacmanLocalDBPath, filepath.Join(pacmanSyncDBPath, "local")).CombinedOutput(); err != nil {
+		return fmt.Errorf("ошибка создания копии базы пакетов: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	if _, err := runPrivileged("pacman", "-Sy", "--dbpath", pacmanSyncDBPath, "--logfile", "/dev/null"); err != nil {
+		return fmt.Errorf("ошибка синхронизации базы пакетов: %v", err)
+	}
+	return nil
+}
+
+// ListUpgradable возвращает список пакетов, доступных для обновления, по последней синхронизированной копии базы
+// До первой синхронизации используется системная база
+func (p *pacmanManager) ListUpgradable() ([]UpgradablePackage, error) {
+	output, err := runPrivileged("pacman", append([]string{"-Qu"}, p.dbPathArgs()...)...)
+	// pacman -Qu возвращает 1, если обновлений нет
+	if err != nil && exitCode(err) == 1 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки обновлений: %v", err)
+	}
+
+	return parsePacmanUpdates(output), nil
+}
+
+// Simulate выполняет пробное обновление всей системы по свежей копии базы пакетов
+// Выборочное обновление в Arch Linux является частичным и не поддерживается
+// pacman не сообщает об удаляемых пакетах при печати плана, поэтому план содержит только обновления
+func (p *pacmanManager) Simulate(packages []string) (*UpgradePlan, error) {
+	if len(packages) > 0 {
+		return nil, fmt.Errorf("pacman не поддерживает выборочное обновление пакетов (частичное обновление), обновите всю систему")
+	}
+	// План должен совпадать с тем, что установит pacman -Syu после синхронизации
+	if err := p.Refresh(); err != nil {
+		return nil, err
+	}
+
+	output, err := runPrivileged("pacman", "-Sup", "--dbpath", pacmanSyncDBPath, "--logfile", "/dev/null", "--print-format", "%n %v")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка пробного обновления: %v", err)
+	}
+
+	plan := &UpgradePlan{}
+	for _, line := range nonEmptyLines(output) {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.HasPrefix(line, "::") {
+			continue
+		}
+		plan.Upgrade = append(plan.Upgrade, PackageChange{Name: fields[0], Version: fields[1]})
+	}
+
+	return plan, nil
+}
+
+// UpgradeCommand возвращает команду обновления всей системы
+// Указанные пакеты устанавливаются в рамках полного обновления, чтобы не допустить частичного обновления
+func (p *pacmanManager) UpgradeCommand(packages []string) *exec.Cmd {
+	return privilegedCommand("pacman", append([]string{"-Syu", "--needed", "--noconfirm"}, packages...)...)
+}
+
+// dbPathArgs возвращает аргументы для работы с копией базы пакетов, если она уже синхронизирована
+func (p *pacmanManager) dbPathArgs() []string {
+	if _, err := os.Stat(filepath.Join(pacmanSyncDBPath, "sync")); err != nil {
+		return nil
+	}
+	return []string{"--dbpath", pacmanSyncDBPath}
+}
+
+// ParseProgress извлекает этап и пакет из строки вывода pacman
+func (p *pacmanManager) ParseProgress(line string) (string, string) {
+	trimmed := strings.TrimSpace(line)
+
+	// ( 1/12) upgrading openssl
+	if strings.HasPrefix(trimmed, "(") {
+		if idx := strings.Index(trimmed, ")"); idx != -1 {
+			fields := strings.Fields(trimmed[idx+1:])
+			if len(fields) >= 2 {
+				return fields[0], fields[len(fields)-1]
+			}
+		}
+	}
+
+	// :: Retrieving packages...
+	if strings.HasPrefix(trimmed, ":: ") {
+		return strings.TrimSuffix(strings.TrimPrefix(trimmed, ":: "), "..."), ""
+	}
+	return "", ""
+}
+
+// HeldBack возвращает пакеты, пропущенные из-за IgnorePkg
+func (p *pacmanManager) HeldBack(output string) []string {
+	var packages []string
+	for _, line := range strings.Split(output, "\n") {
+		// warning: openssl: ignoring package upgrade (3.0.1 => 3.0.2)
+		if strings.Contains(line, "ignoring package upgrade") {
+			fields := strings.Fields(line)
+			if len(fields) > 1 {
+				packages = append(packages, strings.TrimSuffix(fields[1], ":"))
+			}
+		}
+	}
+	return packages
+}
+
+// parsePacmanUpdates парсит вывод pacman -Qu
+// Формат строки: name current -> candidate
+func parsePacmanUpdates(output string) []UpgradablePackage {
+	var packages []UpgradablePackage
+
+	for _, line := range nonEmptyLines(output) {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[2] != "->" {
+			continue
+		}
+
+		packages = append(packages, UpgradablePackage{
+			Name:      fields[0],
+			Current:   fields[1],
+			Candidate: fields[3],
+		})
+	}
+
+	return packages
+}
+
+// checkRootOwnedDir проверяет, что путь является каталогом root, а не символической ссылкой,
+// прежде чем root будет записывать в него через sudo
+func checkRootOwnedDir(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки каталога %s: %v", path, err)
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !info.IsDir() || !ok || stat.Uid != 0 || info.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf("каталог %s должен принадлежать root и быть недоступным для записи другим пользователям", path)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package system
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// processSampleWindow интервал, за который измеряется загрузка CPU процессами
+const processSampleWindow = time.Second
+
+// maxCommandLength максимальная длина командной строки процесса в отчетах
+const maxCommandLength = 80
+
+// ProcessInfo информация о процессе
+type ProcessInfo struct {
+	PID     int32
+	User    string
+	Name    string
+	Command string
+	// CPUPercent загрузка CPU за интервал измерения; 100% соответствует одному ядру
+	CPUPercent    float64
+	MemoryPercent float32
+	RSS           uint64
+	Nice          int32
+	// CreateTime время запуска процесса в миллисекундах; вместе с PID однозначно определяет процесс
+	CreateTime int64
+}
+
+// StartTime возвращает время запуска процесса
+func (p *ProcessInfo) StartTime() time.Time {
+	return time.UnixMilli(p.CreateTime)
+}
+
+// Summary возвращает краткое описание процесса в одну строку
+func (p *ProcessInfo) Summary() string {
+	return fmt.Sprintf("%d %s %s: CPU %.1f%%, RSS %.1f MB", p.PID, p.User, p.Name, p.CPUPercent, float64(p.RSS)/(1<<20))
+}
+
+// TopProcesses процессы с наибольшей загрузкой CPU и потреблением памяти
+type TopProcesses struct {
+	ByCPU    []*ProcessInfo
+	ByMemory []*ProcessInfo
+}
+
+// processSample промежуточные данные процесса при измерении
+type processSample struct {
+	proc    *process.Process
+	cpuTime float64
+	info    *ProcessInfo
+}
+
+// GetTopProcesses получает limit процессов с наибольшей загрузкой CPU и limit с наибольшим RSS
+// Загрузка CPU измеряется за processSampleWindow, поэтому вызов блокируется на это время
+func (m *Monitor) GetTopProcesses(limit int) (*TopProcesses, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make(map[int32]*processSample, len(procs))
+	for _, proc := range procs {
+		times, err := proc.Times()
+		if err != nil {
+			// Процесс завершился или недоступен
+			continue
+		}
+		samples[proc.Pid] = &processSample{proc: proc, cpuTime: times.User + times.System}
+	}
+
+	started := time.Now()
+	time.Sleep(processSampleWindow)
+	elapsed := time.Since(started).Seconds()
+
+	list := make([]*processSample, 0, len(samples))
+	for _, sample := range samples {
+		times, err := sample.proc.Times()
+		if err != nil {
+			continue
+		}
+		memInfo, err := sample.proc.MemoryInfo()
+		if err != nil {
+			continue
+		}
+		sample.info = &ProcessInfo{
+			PID:        sample.proc.Pid,
+			CPUPercent: (times.User + times.System - sample.cpuTime) / elapsed * 100,
+			RSS:        memInfo.RSS,
+		}
+		list = append(list, sample)
+	}
+
+	top := &TopProcesses{}
+	sort.Slice(list, func(i, j int) bool { return list[i].info.CPUPercent > list[j].info.CPUPercent })
+	for i := 0; i < len(list) && i < limit; i++ {
+		top.ByCPU = append(top.ByCPU, fillProcessInfo(list[i]))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].info.RSS > list[j].info.RSS })
+	for i := 0; i < len(list) && i < limit; i++ {
+		top.ByMemory = append(top.ByMemory, fillProcessInfo(list[i]))
+	}
+
+	return top, nil
+}
+
+// fillProcessInfo дополняет информацию о процессе; подробности читаются только для попавших в отчет
+func fillProcessInfo(sample *processSample) *ProcessInfo {
+	info := sample.info
+	if info.Name != "" {
+		return info
+	}
+
+	proc := sample.proc
+	info.Name, _ = proc.Name()
+	if info.Name == "" {
+		info.Name = "?"
+	}
+	info.User, _ = proc.Username()
+	if info.User == "" {
+		info.User = "?"
+	}
+	info.Command, _ = proc.Cmdline()
+	if info.Command == "" {
+		info.Command = "[" + info.Name + "]"
+	}
+	if command := []rune(info.Command); len(command) > maxCommandLength {
+		info.Command = string(command[:maxCommandLength]) + "…"
+	}
+	info.MemoryPercent, _ = proc.MemoryPercent()
+	// gopsutil возвращает поле priority из /proc/<pid>/stat, поэтому nice читается через getpriority;
+	// системный вызов возвращает значение 20 - nice
+	if priority, err := syscall.Getpriority(syscall.PRIO_PROCESS, int(proc.Pid)); err == nil {
+		info.Nice = int32(20 - priority)
+	}
+	info.CreateTime, _ = proc.CreateTime()
+	return info
+}
+
+// GetProcess получает информацию о процессе и проверяет, что PID не занят другим процессом
+func (m *Monitor) GetProcess(pid int32, createTime int64) (*ProcessInfo, error) {
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		return nil, fmt.Errorf("процесс %d не найден", pid)
+	}
+
+	memInfo, err := proc.MemoryInfo()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения информации о процессе %d: %v", pid, err)
+	}
+
+	info := fillProcessInfo(&processSample{proc: proc, info: &ProcessInfo{PID: pid, RSS: memInfo.RSS}})
+	if createTime != 0 && info.CreateTime != createTime {
+		return nil, fmt.Errorf("процесс %d уже завершился", pid)
+	}
+	return info, nil
+}
+
+// SignalProcess отправляет процессу сигнал TERM или KILL
+func (m *Monitor) SignalProcess(pid int32, createTime int64, signal string) error {
+	if signal != "TERM" && signal != "KILL" {
+		return fmt.Errorf("неподдерживаемый сигнал %s", signal)
+	}
+	if err := m.checkProcessAction(pid, createTime); err != nil {
+		return err
+	}
+
+	output, err := privilegedCommand("kill", "-"+signal, strconv.Itoa(int(pid))).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ошибка отправки сигнала: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ReniceProcess изменяет приоритет процесса
+func (m *Monitor) ReniceProcess(pid int32, createTime int64, nice int) error {
+	if nice < -20 || nice > 19 {
+		return fmt.Errorf("приоритет должен быть от -20 до 19")
+	}
+	if err := m.checkProcessAction(pid, createTime); err != nil {
+		return err
+	}
+
+	output, err := privilegedCommand("renice", "-n", strconv.Itoa(nice), "-p", strconv.Itoa(int(pid))).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ошибка изменения приоритета: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// checkProcessAction проверяет, что действие над процессом допустимо
+func (m *Monitor) checkProcessAction(pid int32, createTime int64) error {
+	if pid <= 1 {
+		return fmt.Errorf("действие над процессом %d запрещено", pid)
+	}
+	if int(pid) == os.Getpid() {
+		return fmt.Errorf("действие над процессом бота запрещено")
+	}
+	// PID мог быть переиспользован после завершения процесса, показанного пользователю
+	_, err := m.GetProcess(pid, createTime)
+	return err
+}
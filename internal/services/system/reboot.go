@@ -0,0 +1,118 @@
+package system
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// Файлы, создаваемые в Debian/Ubuntu пакетами, требующими перезагрузки
+const (
+	rebootRequiredFile     = "/var/run/reboot-required"
+	rebootRequiredPkgsFile = "/var/run/reboot-required.pkgs"
+)
+
+// RebootStatus информация о необходимости перезагрузки
+type RebootStatus struct {
+	Required bool
+	Packages []string
+}
+
+// GetRebootStatus проверяет, требуется ли перезагрузка после обновлений, и какие пакеты её вызвали
+func (m *Monitor) GetRebootStatus() *RebootStatus {
+	status := &RebootStatus{}
+
+	if _, err := os.Stat(rebootRequiredFile); err == nil {
+		status.Required = true
+		if data, err := os.ReadFile(rebootRequiredPkgsFile); err == nil {
+			status.Packages = uniqueLines(string(data))
+		}
+		return status
+	}
+
+	// В RHEL-подобных системах needs-restarting -r возвращает 1, если требуется перезагрузка,
+	// и перечисляет обновленные пакеты в строках вида "  * kernel"
+	if commandExists("needs-restarting") {
+		output, err := exec.Command("needs-restarting", "-r").Output()
+		if exitCode(err) == 1 {
+			status.Required = true
+			for _, line := range nonEmptyLines(string(output)) {
+				trimmed := strings.TrimSpace(line)
+				if strings.HasPrefix(trimmed, "* ") {
+					status.Packages = append(status.Packages, strings.TrimPrefix(trimmed, "* "))
+				}
+			}
+		}
+	}
+
+	return status
+}
+
+// GetUptime возвращает время работы системы и время загрузки
+func (m *Monitor) GetUptime() (time.Duration, time.Time, error) {
+	bootTime, err := host.BootTime()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	boot := time.Unix(int64(bootTime), 0)
+	return time.Since(boot), boot, nil
+}
+
+// GetFailedServices возвращает список systemd сервисов в состоянии failed
+func (m *Monitor) GetFailedServices() ([]string, error) {
+	output, err := exec.Command("systemctl", "list-units", "--type=service", "--state=failed", "--no-legend", "--plain", "--no-pager").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var services []string
+	for _, line := range nonEmptyLines(string(output)) {
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			services = append(services, strings.TrimSuffix(fields[0], ".service"))
+		}
+	}
+	return services, nil
+}
+
+// ServicesNeedingRestart возвращает сервисы, использующие обновленные библиотеки
+func ServicesNeedingRestart() []string {
+	var services []string
+
+	switch {
+	case commandExists("needrestart"):
+		// В пакетном режиме needrestart выводит строки вида "NEEDRESTART-SVC: nginx.service"
+		output, _ := runPrivileged("needrestart", "-b", "-r", "l")
+		for _, line := range nonEmptyLines(output) {
+			if strings.HasPrefix(line, "NEEDRESTART-SVC:") {
+				service := strings.TrimSpace(strings.TrimPrefix(line, "NEEDRESTART-SVC:"))
+				services = append(services, strings.TrimSuffix(service, ".service"))
+			}
+		}
+	case commandExists("needs-restarting"):
+		output, _ := runPrivileged("needs-restarting", "-s")
+		for _, line := range nonEmptyLines(output) {
+			services = append(services, strings.TrimSuffix(strings.TrimSpace(line), ".service"))
+		}
+	}
+
+	return services
+}
+
+// uniqueLines возвращает непустые строки без повторов с сохранением порядка
+func uniqueLines(s string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, line := range nonEmptyLines(s) {
+		line = strings.TrimSpace(line)
+		if !seen[line] {
+			seen[line] = true
+			result = append(result, line)
+		}
+	}
+	return result
+}
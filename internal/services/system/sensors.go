@@ -0,0 +1,116 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/common"
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// TemperatureSensor показание датчика температуры в градусах Цельсия
+type TemperatureSensor struct {
+	Name    string
+	Current float64
+	// High и Critical пороги, заданные драйвером датчика; 0 означает отсутствие порога
+	High     float64
+	Critical float64
+}
+
+// FanSensor показание датчика скорости вентилятора
+type FanSensor struct {
+	Name string
+	RPM  int
+}
+
+// SensorsInfo показания датчиков температуры и вентиляторов
+type SensorsInfo struct {
+	Temperatures []TemperatureSensor
+	Fans         []FanSensor
+}
+
+// Empty проверяет, что датчики не обнаружены (например, на виртуальной машине)
+func (s *SensorsInfo) Empty() bool {
+	return len(s.Temperatures) == 0 && len(s.Fans) == 0
+}
+
+// GetSensors получает показания датчиков температуры и вентиляторов
+// Отсутствие датчиков не является ошибкой: возвращается пустой результат
+func (m *Monitor) GetSensors() (*SensorsInfo, error) {
+	return ReadSensors(hostSysPath())
+}
+
+// ReadSensors читает датчики hwmon и thermal_zone из дерева sysfs с корнем sysPath
+// Корень передается явно, чтобы разбор можно было проверить на подготовленном дереве каталогов
+func ReadSensors(sysPath string) (*SensorsInfo, error) {
+	ctx := context.WithValue(context.Background(), common.EnvKey, common.EnvMap{common.HostSysEnvKey: sysPath})
+	temperatures, err := host.SensorsTemperaturesWithContext(ctx)
+	// gopsutil возвращает предупреждения о нечитаемых датчиках вместе с показаниями остальных
+	if err != nil && len(temperatures) == 0 {
+		return nil, fmt.Errorf("ошибка чтения датчиков температуры: %v", err)
+	}
+
+	info := &SensorsInfo{}
+	for _, temperature := range temperatures {
+		info.Temperatures = append(info.Temperatures, TemperatureSensor{
+			Name:     temperature.SensorKey,
+			Current:  temperature.Temperature,
+			High:     temperature.High,
+			Critical: temperature.Critical,
+		})
+	}
+	sort.Slice(info.Temperatures, func(i, j int) bool { return info.Temperatures[i].Name < info.Temperatures[j].Name })
+
+	info.Fans = readFans(sysPath)
+	return info, nil
+}
+
+// readFans читает скорость вентиляторов из файлов hwmon fan*_input
+func readFans(sysPath string) []FanSensor {
+	files, _ := filepath.Glob(filepath.Join(sysPath, "class", "hwmon", "hwmon*", "fan*_input"))
+	if len(files) == 0 {
+		// В некоторых дистрибутивах датчики находятся в промежуточном каталоге device
+		files, _ = filepath.Glob(filepath.Join(sysPath, "class", "hwmon", "hwmon*", "device", "fan*_input"))
+	}
+
+	var fans []FanSensor
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		rpm, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+		if err != nil {
+			continue
+		}
+
+		// Имя вида "nct6775_cpu_fan" или "nct6775_fan1", как у датчиков температуры
+		directory := filepath.Dir(file)
+		base := strings.TrimSuffix(filepath.Base(file), "_input")
+		name := base
+		if raw, err := os.ReadFile(filepath.Join(directory, base+"_label")); err == nil && len(strings.TrimSpace(string(raw))) > 0 {
+			name = strings.Join(strings.Fields(strings.ToLower(string(raw))), "_")
+		}
+		if raw, err := os.ReadFile(filepath.Join(directory, "name")); err == nil {
+			name = strings.TrimSpace(string(raw)) + "_" + name
+		}
+
+		fans = append(fans, FanSensor{Name: name, RPM: rpm})
+	}
+
+	sort.Slice(fans, func(i, j int) bool { return fans[i].Name < fans[j].Name })
+	return fans
+}
+
+// hostSysPath возвращает корень sysfs; HOST_SYS задается при запуске в контейнере
+func hostSysPath() string {
+	if sysPath := os.Getenv("HOST_SYS"); sysPath != "" {
+		return sysPath
+	}
+	return "/sys"
+}
@@ -0,0 +1,90 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeSysFile создает файл sysfs с содержимым внутри тестового дерева
+func writeSysFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadSensorsHwmon(t *testing.T) {
+	root := t.TempDir()
+	writeSysFile(t, root, "class/hwmon/hwmon0/name", "coretemp")
+	writeSysFile(t, root, "class/hwmon/hwmon0/temp1_input", "45000")
+	writeSysFile(t, root, "class/hwmon/hwmon0/temp1_max", "80000")
+	writeSysFile(t, root, "class/hwmon/hwmon0/temp1_crit", "100000")
+	writeSysFile(t, root, "class/hwmon/hwmon0/temp1_label", "Package id 0")
+	writeSysFile(t, root, "class/hwmon/hwmon1/name", "nct6775")
+	writeSysFile(t, root, "class/hwmon/hwmon1/fan1_input", "1200")
+	writeSysFile(t, root, "class/hwmon/hwmon1/fan2_input", "850")
+	writeSysFile(t, root, "class/hwmon/hwmon1/fan2_label", "CPU Fan")
+	// Нечитаемое показание пропускается
+	writeSysFile(t, root, "class/hwmon/hwmon1/fan3_input", "n/a")
+
+	info, err := ReadSensors(root)
+	if err != nil {
+		t.Fatalf("ReadSensors: %v", err)
+	}
+
+	wantTemperatures := []TemperatureSensor{
+		{Name: "coretemp_package_id_0", Current: 45, High: 80, Critical: 100},
+	}
+	if !reflect.DeepEqual(info.Temperatures, wantTemperatures) {
+		t.Errorf("Temperatures = %+v, want %+v", info.Temperatures, wantTemperatures)
+	}
+	wantFans := []FanSensor{
+		{Name: "nct6775_cpu_fan", RPM: 850},
+		{Name: "nct6775_fan1", RPM: 1200},
+	}
+	if !reflect.DeepEqual(info.Fans, wantFans) {
+		t.Errorf("Fans = %+v, want %+v", info.Fans, wantFans)
+	}
+}
+
+func TestReadSensorsThermalZone(t *testing.T) {
+	root := t.TempDir()
+	writeSysFile(t, root, "class/thermal/thermal_zone0/type", "acpitz")
+	writeSysFile(t, root, "class/thermal/thermal_zone0/temp", "52000")
+
+	info, err := ReadSensors(root)
+	if err != nil {
+		t.Fatalf("ReadSensors: %v", err)
+	}
+
+	want := []TemperatureSensor{{Name: "acpitz", Current: 52}}
+	if !reflect.DeepEqual(info.Temperatures, want) {
+		t.Errorf("Temperatures = %+v, want %+v", info.Temperatures, want)
+	}
+	if len(info.Fans) != 0 {
+		t.Errorf("Fans = %+v, want none", info.Fans)
+	}
+}
+
+func TestReadSensorsEmpty(t *testing.T) {
+	roots := map[string]string{
+		"пустой каталог":        t.TempDir(),
+		"отсутствующий каталог": filepath.Join(t.TempDir(), "missing"),
+	}
+	for name, root := range roots {
+		info, err := ReadSensors(root)
+		if err != nil {
+			t.Errorf("%s: ReadSensors: %v", name, err)
+			continue
+		}
+		if !info.Empty() {
+			t.Errorf("%s: ReadSensors = %+v, want empty", name, info)
+		}
+	}
+}
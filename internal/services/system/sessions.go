@@ -0,0 +1,103 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// terminalPattern допустимые имена терминалов: pts/0, tty1, ttyS0, console
+var terminalPattern = regexp.MustCompile(`^(?:pts/\d+|tty[\w]+|console)$`)
+
+// Session сеанс пользователя из utmp
+type Session struct {
+	User     string
+	Terminal string
+	// Host адрес, с которого выполнен вход; пустой для локальных сеансов
+	Host    string
+	Started time.Time
+	// Idle время бездействия терминала; отрицательное, если его не удалось определить
+	Idle time.Duration
+}
+
+// GetSessions получает список сеансов пользователей, упорядоченный по времени входа
+func (m *Monitor) GetSessions() ([]Session, error) {
+	users, err := host.Users()
+	// Без utmp (например, в минимальном контейнере) сеансов нет
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения списка сеансов: %v", err)
+	}
+
+	now := time.Now()
+	sessions := make([]Session, 0, len(users))
+	for _, user := range users {
+		sessions = append(sessions, Session{
+			User:     user.User,
+			Terminal: user.Terminal,
+			Host:     user.Host,
+			Started:  time.Unix(int64(user.Started), 0),
+			Idle:     terminalIdle(user.Terminal, now),
+		})
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Started.Before(sessions[j].Started) })
+	return sessions, nil
+}
+
+// TerminateSession завершает все процессы сеанса на терминале
+// Время входа защищает от завершения нового сеанса, занявшего тот же терминал
+func (m *Monitor) TerminateSession(terminal string, started int64) error {
+	if !terminalPattern.MatchString(terminal) {
+		return fmt.Errorf("некорректный терминал %q", terminal)
+	}
+
+	sessions, err := m.GetSessions()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, session := range sessions {
+		if session.Terminal == terminal && session.Started.Unix() == started {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("сеанс на %s уже завершен", terminal)
+	}
+
+	// SIGHUP игнорируется некоторыми программами, поэтому процессы терминала завершаются принудительно
+	output, err := privilegedCommand("pkill", "-KILL", "-t", terminal).CombinedOutput()
+	// pkill возвращает 1, если на терминале не осталось процессов
+	if err != nil && exitCode(err) != 1 {
+		return fmt.Errorf("ошибка завершения сеанса: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// terminalIdle возвращает время бездействия терминала по времени последнего чтения устройства, как who и w
+func terminalIdle(terminal string, now time.Time) time.Duration {
+	info, err := os.Stat(filepath.Join("/dev", terminal))
+	if err != nil {
+		return -1
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return -1
+	}
+	idle := now.Sub(time.Unix(stat.Atim.Sec, stat.Atim.Nsec))
+	if idle < 0 {
+		return 0
+	}
+	return idle
+}
@@ -0,0 +1,228 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UpgradeReport итоги завершенного обновления
+type UpgradeReport struct {
+	HeldBack          []string
+	ServicesToRestart []string
+	RebootRequired    bool
+}
+
+// UpgradeProgress текущее состояние задачи обновления
+type UpgradeProgress struct {
+	Phase    string
+	Package  string
+	LastLine string
+	Elapsed  time.Duration
+	Done     bool
+	Err      error
+	Report   *UpgradeReport
+}
+
+// UpgradeJob фоновая задача обновления системы
+type UpgradeJob struct {
+	ID        string
+	Manager   string
+	Packages  []string
+	StartedAt time.Time
+	LogPath   string
+
+	mu         sync.Mutex
+	phase      string
+	pkg        string
+	lastLine   string
+	finishedAt time.Time
+	err        error
+	report     *UpgradeReport
+	done       chan struct{}
+}
+
+// Progress возвращает текущее состояние задачи
+func (j *UpgradeJob) Progress() UpgradeProgress {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	progress := UpgradeProgress{
+		Phase:    j.phase,
+		Package:  j.pkg,
+		LastLine: j.lastLine,
+		Err:      j.err,
+		Report:   j.report,
+	}
+	if j.finishedAt.IsZero() {
+		progress.Elapsed = time.Since(j.StartedAt)
+	} else {
+		progress.Done = true
+		progress.Elapsed = j.finishedAt.Sub(j.StartedAt)
+	}
+	return progress
+}
+
+// Done возвращает канал, закрываемый по завершении задачи
+func (j *UpgradeJob) Done() <-chan struct{} {
+	return j.done
+}
+
+// StartUpgrade запускает фоновое обновление указанных пакетов или всей системы
+func (m *Monitor) StartUpgrade(packages ...string) (*UpgradeJob, error) {
+	if m.packageManager == nil {
+		return nil, errNoPackageManager
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.upgradeJob != nil {
+		select {
+		case <-m.upgradeJob.done:
+		default:
+			return nil, fmt.Errorf("обновление %s уже выполняется", m.upgradeJob.ID)
+		}
+	}
+
+	logDir := filepath.Join(m.dataDir, "upgrade-logs")
+	if err := os.MkdirAll(logDir, 0750); err != nil {
+		return nil, fmt.Errorf("ошибка создания директории журналов: %v", err)
+	}
+
+	startedAt := time.Now()
+	job := &UpgradeJob{
+		ID:        startedAt.Format("20060102-150405"),
+		Manager:   m.packageManager.Name(),
+		Packages:  packages,
+		StartedAt: startedAt,
+		phase:     "Запуск",
+		done:      make(chan struct{}),
+	}
+	job.LogPath = filepath.Join(logDir, job.ID+".log")
+
+	logFile, err := os.Create(job.LogPath)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания журнала обновления: %v", err)
+	}
+
+	cmd := m.packageManager.UpgradeCommand(packages)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		logFile.Close()
+		return nil, err
+	}
+	// Объединяем stderr со stdout, чтобы журнал содержал полный вывод
+	cmd.Stderr = cmd.Stdout
+
+	fmt.Fprintf(logFile, "$ %s\n\n", strings.Join(cmd.Args, " "))
+	if err := cmd.Start(); err != nil {
+		logFile.Close()
+		return nil, fmt.Errorf("ошибка запуска обновления: %v", err)
+	}
+
+	m.upgradeJob = job
+	go m.runUpgradeJob(job, cmd, stdout, logFile)
+
+	return job, nil
+}
+
+// UpgradeRunning проверяет, выполняется ли сейчас обновление системы
+func (m *Monitor) UpgradeRunning() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.upgradeJob == nil {
+		return false
+	}
+	select {
+	case <-m.upgradeJob.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// GetUpgradeLog возвращает журнал обновления с указанным ID
+func (m *Monitor) GetUpgradeLog(id string) ([]byte, error) {
+	// ID формируется из даты, поэтому не может содержать разделителей пути
+	if filepath.Base(id) != id {
+		return nil, fmt.Errorf("некорректный идентификатор журнала: %s", id)
+	}
+
+	return os.ReadFile(filepath.Join(m.dataDir, "upgrade-logs", id+".log"))
+}
+
+// runUpgradeJob читает вывод обновления, отслеживает прогресс и формирует итоговый отчет
+func (m *Monitor) runUpgradeJob(job *UpgradeJob, cmd *exec.Cmd, stdout io.Reader, logFile *os.File) {
+	defer close(job.done)
+	defer logFile.Close()
+
+	var output bytes.Buffer
+	reader := io.TeeReader(stdout, io.MultiWriter(logFile, &output))
+	scanner := bufio.NewScanner(reader)
+	scanner.Split(scanProgressLines)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		phase, pkg := m.packageManager.ParseProgress(line)
+		job.mu.Lock()
+		job.lastLine = line
+		if phase != "" {
+			job.phase = phase
+			job.pkg = pkg
+		}
+		job.mu.Unlock()
+	}
+
+	// Сканер останавливается на слишком длинной строке; недочитанный вывод заблокировал бы команду на записи
+	scanErr := scanner.Err()
+	io.Copy(io.Discard, reader)
+
+	err := cmd.Wait()
+	switch {
+	case err != nil:
+		err = fmt.Errorf("ошибка обновления системы: %v", err)
+	case scanErr != nil:
+		err = fmt.Errorf("ошибка чтения вывода обновления: %v", scanErr)
+	}
+
+	report := &UpgradeReport{
+		HeldBack:          m.packageManager.HeldBack(output.String()),
+		ServicesToRestart: ServicesNeedingRestart(),
+		RebootRequired:    m.GetRebootStatus().Required,
+	}
+	fmt.Fprintf(logFile, "\nЗавершено: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+
+	job.mu.Lock()
+	job.phase = "Завершено"
+	job.pkg = ""
+	job.err = err
+	job.report = report
+	job.finishedAt = time.Now()
+	job.mu.Unlock()
+}
+
+// scanProgressLines разбивает вывод на строки по \n и \r, так как индикаторы прогресса перерисовывают строку
+func scanProgressLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,340 @@
+package traffic
+
+import (
+	"log"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"tgbot/internal/services/system"
+	"tgbot/pkg/config"
+	"tgbot/pkg/storage"
+)
+
+// trafficStateFileName файл состояния учета трафика в директории данных
+const trafficStateFileName = "traffic.json"
+
+// trafficCheckInterval период чтения счетчиков трафика
+const trafficCheckInterval = time.Minute
+
+// minProjectionSpan минимальная длительность учета, после которой строится прогноз расхода квоты
+const minProjectionSpan = 6 * time.Hour
+
+// Направления учета трафика
+const (
+	DirectionBoth = "both"
+	DirectionRx   = "rx"
+	DirectionTx   = "tx"
+)
+
+// Usage объем принятого и переданного трафика в байтах
+type Usage struct {
+	Rx uint64 `json:"rx"`
+	Tx uint64 `json:"tx"`
+}
+
+// Total возвращает объем трафика в учитываемом направлении
+func (u Usage) Total(direction string) uint64 {
+	switch direction {
+	case DirectionRx:
+		return u.Rx
+	case DirectionTx:
+		return u.Tx
+	default:
+		return u.Rx + u.Tx
+	}
+}
+
+// counters последние прочитанные счетчики интерфейса
+type counters struct {
+	Rx uint64 `json:"rx"`
+	Tx uint64 `json:"tx"`
+}
+
+// state состояние учета, сохраняемое между запусками
+type state struct {
+	PeriodStart time.Time `json:"period_start"`
+	// TrackedSince начало учета в текущем периоде; позже начала периода при первом запуске
+	TrackedSince   time.Time            `json:"tracked_since"`
+	Interfaces     map[string]*Usage    `json:"interfaces"`
+	PreviousStart  time.Time            `json:"previous_start"`
+	PreviousTotals map[string]*Usage    `json:"previous_totals,omitempty"`
+	BootTime       time.Time            `json:"boot_time"`
+	Last           map[string]*counters `json:"last"`
+}
+
+// Report трафик за текущий расчетный период
+type Report struct {
+	PeriodStart  time.Time
+	PeriodEnd    time.Time
+	TrackedSince time.Time
+	Direction    string
+	Interfaces   map[string]Usage
+	Total        Usage
+	// Used трафик в учитываемом направлении
+	Used uint64
+	// Quota квота на период в байтах, 0 - без квоты
+	Quota uint64
+	// Projected ожидаемый трафик к концу периода при текущем темпе; 0, если данных недостаточно
+	Projected uint64
+	// Previous трафик за прошлый период, если он учитывался
+	Previous *Usage
+}
+
+// Names возвращает имена интерфейсов в отчете по алфавиту
+func (r *Report) Names() []string {
+	names := make([]string, 0, len(r.Interfaces))
+	for name := range r.Interfaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UsedPercent возвращает израсходованную долю квоты в процентах
+func (r *Report) UsedPercent() float64 {
+	if r.Quota == 0 {
+		return 0
+	}
+	return float64(r.Used) / float64(r.Quota) * 100
+}
+
+// ProjectedPercent возвращает прогнозируемую к концу периода долю квоты в процентах
+func (r *Report) ProjectedPercent() float64 {
+	if r.Quota == 0 {
+		return 0
+	}
+	return float64(r.Projected) / float64(r.Quota) * 100
+}
+
+// Accountant ведет учет трафика за расчетный период с сохранением между перезапусками
+type Accountant struct {
+	config        config.TrafficConfig
+	systemService *system.Monitor
+	statePath     string
+	state         *state
+	mu            sync.Mutex
+	stopChan      chan struct{}
+}
+
+// NewAccountant создает сервис учета трафика
+func NewAccountant(cfg *config.Config, systemService *system.Monitor) *Accountant {
+	return &Accountant{
+		config:        cfg.Traffic,
+		systemService: systemService,
+		statePath:     filepath.Join(cfg.Storage.DataDir, trafficStateFileName),
+		stopChan:      make(chan struct{}),
+	}
+}
+
+// Start загружает сохраненное состояние и запускает учет трафика
+func (a *Accountant) Start() {
+	a.mu.Lock()
+	a.state = &state{}
+	if err := storage.LoadJSON(a.statePath, a.state); err != nil {
+		log.Printf("Traffic: Ошибка загрузки состояния учета трафика: %v", err)
+		a.state = &state{}
+	}
+	a.mu.Unlock()
+
+	a.update(time.Now())
+	go a.run()
+}
+
+// Stop останавливает учет трафика, сохраняя последние значения
+func (a *Accountant) Stop() {
+	close(a.stopChan)
+	a.update(time.Now())
+}
+
+// run периодически обновляет учет трафика
+func (a *Accountant) run() {
+	ticker := time.NewTicker(trafficCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			a.update(now)
+		case <-a.stopChan:
+			return
+		}
+	}
+}
+
+// update добавляет трафик с момента предыдущего чтения счетчиков
+func (a *Accountant) update(now time.Time) {
+	current, err := a.systemService.GetNetCounters()
+	if err != nil {
+		log.Printf("Traffic: Ошибка получения счетчиков трафика: %v", err)
+		return
+	}
+	_, bootTime, err := a.systemService.GetUptime()
+	if err != nil {
+		log.Printf("Traffic: Ошибка получения времени загрузки: %v", err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s := a.state
+	start := periodStart(now, a.resetDay())
+	if !s.PeriodStart.Equal(start) {
+		// Начался новый расчетный период: итоги сохраняются как прошлый период
+		if !s.PeriodStart.IsZero() {
+			s.PreviousStart, s.PreviousTotals = s.PeriodStart, s.Interfaces
+		}
+		s.PeriodStart, s.TrackedSince = start, now
+		s.Interfaces = make(map[string]*Usage)
+	}
+	if s.Interfaces == nil {
+		s.Interfaces = make(map[string]*Usage)
+	}
+
+	// При первом запуске неизвестно, какая часть счетчиков относится к текущему периоду,
+	// поэтому они только запоминаются
+	first := s.Last == nil
+	if first {
+		s.Last = make(map[string]*counters)
+	}
+
+	// После перезагрузки счетчики начинаются с нуля, весь их прирост относится к периоду
+	if !first && !s.BootTime.Equal(bootTime) {
+		s.Last = make(map[string]*counters)
+	}
+	s.BootTime = bootTime
+
+	// Трафик контейнеров проходит и через мост или veth, и через внешний интерфейс,
+	// поэтому по умолчанию учитываются только физические интерфейсы, если они есть
+	physical := false
+	for _, counter := range current {
+		physical = physical || counter.Physical
+	}
+
+	for _, counter := range current {
+		if !a.counted(counter, physical) {
+			// Учтенный ранее трафик виртуальных интерфейсов не входит в итоги периода
+			delete(s.Interfaces, counter.Name)
+			continue
+		}
+		last, ok := s.Last[counter.Name]
+		s.Last[counter.Name] = &counters{Rx: counter.BytesRecv, Tx: counter.BytesSent}
+
+		if first {
+			continue
+		}
+		if !ok {
+			last = &counters{}
+		}
+
+		usage, ok := s.Interfaces[counter.Name]
+		if !ok {
+			usage = &Usage{}
+			s.Interfaces[counter.Name] = usage
+		}
+		usage.Rx += counterDelta(last.Rx, counter.BytesRecv)
+		usage.Tx += counterDelta(last.Tx, counter.BytesSent)
+	}
+
+	if err := storage.SaveJSON(a.statePath, s); err != nil {
+		log.Printf("Traffic: Ошибка сохранения состояния учета трафика: %v", err)
+	}
+}
+
+// Report возвращает трафик за текущий расчетный период
+func (a *Accountant) Report(now time.Time) *Report {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	start := periodStart(now, a.resetDay())
+	report := &Report{
+		PeriodStart:  start,
+		PeriodEnd:    start.AddDate(0, 1, 0),
+		TrackedSince: now,
+		Direction:    a.direction(),
+		Interfaces:   make(map[string]Usage),
+		Quota:        uint64(a.config.Quota * (1 << 30)),
+	}
+	if a.state == nil {
+		return report
+	}
+
+	s := a.state
+	if s.PeriodStart.Equal(start) {
+		report.TrackedSince = s.TrackedSince
+		for name, usage := range s.Interfaces {
+			report.Interfaces[name] = *usage
+			report.Total.Rx += usage.Rx
+			report.Total.Tx += usage.Tx
+		}
+		if s.PreviousTotals != nil {
+			previous := &Usage{}
+			for _, usage := range s.PreviousTotals {
+				previous.Rx += usage.Rx
+				previous.Tx += usage.Tx
+			}
+			report.Previous = previous
+		}
+	}
+	report.Used = report.Total.Total(report.Direction)
+
+	// Прогноз по среднему темпу с начала учета в текущем периоде
+	tracked := now.Sub(report.TrackedSince)
+	if tracked >= minProjectionSpan {
+		remaining := report.PeriodEnd.Sub(now)
+		report.Projected = report.Used + uint64(float64(report.Used)/tracked.Seconds()*remaining.Seconds())
+	}
+
+	return report
+}
+
+// counted проверяет, учитывается ли трафик интерфейса
+// Без заданного списка учитываются физические интерфейсы, а при их отсутствии (контейнер, OpenVZ) - все
+func (a *Accountant) counted(counter system.NetCounters, physical bool) bool {
+	if len(a.config.Interfaces) == 0 {
+		return !physical || counter.Physical
+	}
+	for _, iface := range a.config.Interfaces {
+		if iface == counter.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// direction возвращает учитываемое направление трафика
+func (a *Accountant) direction() string {
+	switch a.config.Direction {
+	case DirectionRx, DirectionTx:
+		return a.config.Direction
+	default:
+		return DirectionBoth
+	}
+}
+
+// resetDay возвращает день начала расчетного периода; дни после 28-го есть не в каждом месяце
+func (a *Accountant) resetDay() int {
+	if a.config.ResetDay < 1 || a.config.ResetDay > 28 {
+		return 1
+	}
+	return a.config.ResetDay
+}
+
+// periodStart возвращает начало расчетного периода, содержащего now
+func periodStart(now time.Time, resetDay int) time.Time {
+	start := time.Date(now.Year(), now.Month(), resetDay, 0, 0, 0, 0, now.Location())
+	if now.Before(start) {
+		start = start.AddDate(0, -1, 0)
+	}
+	return start
+}
+
+// counterDelta возвращает прирост счетчика; при сбросе счетчика учитывается его текущее значение
+func counterDelta(last, current uint64) uint64 {
+	if current < last {
+		return current
+	}
+	return current - last
+}
@@ -0,0 +1,369 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"sort"
+	"time"
+)
+
+// Размеры изображения и полей графика в пикселях
+const (
+	width        = 960
+	height       = 480
+	marginLeft   = 100
+	marginRight  = 30
+	marginTop    = 50
+	marginBottom = 40
+	// fontScale увеличение растрового шрифта
+	fontScale = 2
+	// yTicks желаемое количество делений оси значений
+	yTicks = 5
+	// xTicks количество делений оси времени
+	xTicks = 6
+)
+
+// Цвета элементов графика
+var (
+	backgroundColor = color.RGBA{255, 255, 255, 255}
+	axisColor       = color.RGBA{90, 90, 90, 255}
+	gridColor       = color.RGBA{225, 225, 225, 255}
+	textColor       = color.RGBA{40, 40, 40, 255}
+
+	// palette цвета линий рядов по порядку
+	palette = []color.RGBA{
+		{31, 119, 180, 255},
+		{44, 160, 44, 255},
+		{148, 103, 189, 255},
+		{255, 127, 14, 255},
+		{23, 190, 207, 255},
+		{140, 86, 75, 255},
+		{227, 119, 194, 255},
+		{127, 127, 127, 255},
+	}
+
+	// WarningColor и CriticalColor цвета линий порогов
+	WarningColor  = color.RGBA{255, 165, 0, 255}
+	CriticalColor = color.RGBA{214, 39, 40, 255}
+)
+
+// Point значение ряда в момент времени
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series ряд значений, отображаемый линией
+type Series struct {
+	Name   string
+	Points []Point
+}
+
+// Threshold горизонтальная линия порога
+type Threshold struct {
+	Label string
+	Value float64
+	Color color.Color
+}
+
+// Chart параметры линейного графика
+type Chart struct {
+	Series     []Series
+	Thresholds []Threshold
+	From, To   time.Time
+	// YMin и YMax задают фиксированный диапазон оси значений; если YMax не больше YMin, диапазон подбирается по данным
+	YMin, YMax float64
+	// FormatValue форматирует подписи оси значений
+	FormatValue func(float64) string
+	// TimeFormat формат подписей оси времени
+	TimeFormat string
+}
+
+// plot область построения графика и преобразование координат
+type plot struct {
+	img              *image.RGBA
+	left, top        int
+	right, bottom    int
+	from, to         time.Time
+	yMin, yMax       float64
+	formatValue      func(float64) string
+	timeFormat       string
+	plotWidthPixels  float64
+	plotHeightPixels float64
+}
+
+// Render строит линейный график и возвращает его в формате PNG
+func Render(c Chart) ([]byte, error) {
+	if !c.To.After(c.From) {
+		return nil, fmt.Errorf("некорректный период графика")
+	}
+
+	p := &plot{
+		img:         image.NewRGBA(image.Rect(0, 0, width, height)),
+		left:        marginLeft,
+		top:         marginTop,
+		right:       width - marginRight,
+		bottom:      height - marginBottom,
+		from:        c.From,
+		to:          c.To,
+		formatValue: c.FormatValue,
+		timeFormat:  c.TimeFormat,
+	}
+	p.plotWidthPixels = float64(p.right - p.left)
+	p.plotHeightPixels = float64(p.bottom - p.top)
+	if p.formatValue == nil {
+		p.formatValue = func(v float64) string { return fmt.Sprintf("%g", v) }
+	}
+	if p.timeFormat == "" {
+		p.timeFormat = "15:04"
+	}
+
+	p.yMin, p.yMax = c.YMin, c.YMax
+	if p.yMax <= p.yMin {
+		p.yMin, p.yMax = autoRange(c)
+	}
+
+	draw.Draw(p.img, p.img.Bounds(), &image.Uniform{backgroundColor}, image.Point{}, draw.Src)
+	p.drawGrid()
+	for _, threshold := range c.Thresholds {
+		p.drawThreshold(threshold)
+	}
+	for i, series := range c.Series {
+		p.drawSeries(series, palette[i%len(palette)])
+	}
+	p.drawLegend(c.Series)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, p.img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// autoRange подбирает диапазон оси значений по данным и порогам
+func autoRange(c Chart) (float64, float64) {
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	for _, series := range c.Series {
+		for _, point := range series.Points {
+			minValue = math.Min(minValue, point.Value)
+			maxValue = math.Max(maxValue, point.Value)
+		}
+	}
+	for _, threshold := range c.Thresholds {
+		minValue = math.Min(minValue, threshold.Value)
+		maxValue = math.Max(maxValue, threshold.Value)
+	}
+
+	if math.IsInf(minValue, 1) {
+		return 0, 1
+	}
+	// Для неотрицательных значений ось начинается с нуля
+	if minValue >= 0 {
+		minValue = 0
+	}
+	if maxValue <= minValue {
+		maxValue = minValue + 1
+	}
+
+	step := niceStep((maxValue - minValue) / yTicks)
+	return math.Floor(minValue/step) * step, math.Ceil(maxValue*1.05/step) * step
+}
+
+// niceStep округляет шаг делений до 1, 2 или 5, умноженных на степень десяти
+func niceStep(raw float64) float64 {
+	exp := math.Pow(10, math.Floor(math.Log10(raw)))
+	switch fraction := raw / exp; {
+	case fraction <= 1:
+		return exp
+	case fraction <= 2:
+		return 2 * exp
+	case fraction <= 5:
+		return 5 * exp
+	default:
+		return 10 * exp
+	}
+}
+
+// x возвращает горизонтальную координату момента времени
+func (p *plot) x(t time.Time) int {
+	ratio := float64(t.Sub(p.from)) / float64(p.to.Sub(p.from))
+	return p.left + int(math.Round(ratio*p.plotWidthPixels))
+}
+
+// y возвращает вертикальную координату значения
+func (p *plot) y(v float64) int {
+	ratio := (v - p.yMin) / (p.yMax - p.yMin)
+	return p.bottom - int(math.Round(ratio*p.plotHeightPixels))
+}
+
+// drawGrid рисует оси, сетку и подписи делений
+func (p *plot) drawGrid() {
+	step := niceStep((p.yMax - p.yMin) / yTicks)
+	for v := math.Ceil(p.yMin/step) * step; v <= p.yMax+step/1000; v += step {
+		y := p.y(v)
+		p.hline(p.left, p.right, y, gridColor, false)
+		label := p.formatValue(v)
+		drawText(p.img, p.left-10-textWidth(label, fontScale), y-glyphHeight*fontScale/2, label, textColor, fontScale)
+	}
+
+	for i := 0; i <= xTicks; i++ {
+		t := p.from.Add(time.Duration(float64(p.to.Sub(p.from)) * float64(i) / xTicks))
+		x := p.x(t)
+		p.vline(x, p.top, p.bottom, gridColor)
+		label := t.Format(p.timeFormat)
+		labelX := x - textWidth(label, fontScale)/2
+		if labelX+textWidth(label, fontScale) > width {
+			labelX = width - textWidth(label, fontScale) - 2
+		}
+		drawText(p.img, labelX, p.bottom+10, label, textColor, fontScale)
+	}
+
+	p.hline(p.left, p.right, p.bottom, axisColor, false)
+	p.vline(p.left, p.top, p.bottom, axisColor)
+}
+
+// drawThreshold рисует пунктирную линию порога с подписью
+func (p *plot) drawThreshold(threshold Threshold) {
+	if threshold.Value < p.yMin || threshold.Value > p.yMax {
+		return
+	}
+	c := threshold.Color
+	if c == nil {
+		c = CriticalColor
+	}
+
+	y := p.y(threshold.Value)
+	p.hline(p.left, p.right, y, c, true)
+	if threshold.Label != "" {
+		drawText(p.img, p.right-textWidth(threshold.Label, fontScale)-4, y-glyphHeight*fontScale-4, threshold.Label, c, fontScale)
+	}
+}
+
+// drawSeries рисует линию ряда; большие промежутки между точками не соединяются
+func (p *plot) drawSeries(series Series, c color.Color) {
+	points := series.Points
+	if len(points) == 0 {
+		return
+	}
+	maxGap := 3 * medianInterval(points)
+
+	for i, point := range points {
+		x, y := p.x(point.Time), p.y(point.Value)
+		if i == 0 || (maxGap > 0 && point.Time.Sub(points[i-1].Time) > maxGap) {
+			p.dot(x, y, c)
+			continue
+		}
+		prev := points[i-1]
+		p.line(p.x(prev.Time), p.y(prev.Value), x, y, c)
+	}
+}
+
+// drawLegend рисует названия рядов над графиком
+func (p *plot) drawLegend(series []Series) {
+	x := p.left
+	y := (marginTop - glyphHeight*fontScale) / 2
+	for i, s := range series {
+		c := palette[i%len(palette)]
+		p.fillRect(x, y, x+glyphHeight*fontScale, y+glyphHeight*fontScale, c)
+		x += glyphHeight*fontScale + 6
+		drawText(p.img, x, y, s.Name, textColor, fontScale)
+		x += textWidth(s.Name, fontScale) + 20
+	}
+}
+
+// medianInterval возвращает медианный интервал между соседними точками
+func medianInterval(points []Point) time.Duration {
+	if len(points) < 2 {
+		return 0
+	}
+	intervals := make([]time.Duration, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		intervals = append(intervals, points[i].Time.Sub(points[i-1].Time))
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+	return intervals[len(intervals)/2]
+}
+
+// line рисует отрезок толщиной 2 пикселя алгоритмом Брезенхэма
+func (p *plot) line(x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	e := dx + dy
+	for {
+		p.dot(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// dot рисует точку 2x2 пикселя в пределах области построения
+func (p *plot) dot(x, y int, c color.Color) {
+	for dx := 0; dx < 2; dx++ {
+		for dy := 0; dy < 2; dy++ {
+			px, py := x+dx, y+dy
+			if px >= p.left && px <= p.right && py >= p.top && py <= p.bottom {
+				p.img.Set(px, py, c)
+			}
+		}
+	}
+}
+
+// hline рисует горизонтальную линию, сплошную или пунктирную
+func (p *plot) hline(x0, x1, y int, c color.Color, dashed bool) {
+	for x := x0; x <= x1; x++ {
+		if dashed && (x/6)%2 == 1 {
+			continue
+		}
+		p.img.Set(x, y, c)
+		if dashed {
+			p.img.Set(x, y+1, c)
+		}
+	}
+}
+
+// vline рисует вертикальную линию
+func (p *plot) vline(x, y0, y1 int, c color.Color) {
+	for y := y0; y <= y1; y++ {
+		p.img.Set(x, y, c)
+	}
+}
+
+// fillRect закрашивает прямоугольник
+func (p *plot) fillRect(x0, y0, x1, y1 int, c color.Color) {
+	draw.Draw(p.img, image.Rect(x0, y0, x1, y1), &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// abs возвращает модуль числа
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// sign возвращает знак числа
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	default:
+		return 0
+	}
+}
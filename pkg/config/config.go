@@ -6,9 +6,20 @@ import (
 
 // Config структура конфигурации приложения
 type Config struct {
-	Bot        BotConfig        `mapstructure:"bot"`
-	Monitoring MonitoringConfig `mapstructure:"monitoring"`
-	Docker     DockerConfig     `mapstructure:"docker"`
+	Bot           BotConfig           `mapstructure:"bot"`
+	Monitoring    MonitoringConfig    `mapstructure:"monitoring"`
+	Docker        DockerConfig        `mapstructure:"docker"`
+	Storage       StorageConfig       `mapstructure:"storage"`
+	Maintenance   MaintenanceConfig   `mapstructure:"maintenance"`
+	Notifications NotificationsConfig `mapstructure:"notifications"`
+	Metrics       MetricsConfig       `mapstructure:"metrics"`
+	Digest        DigestConfig        `mapstructure:"digest"`
+	Exporter      ExporterConfig      `mapstructure:"exporter"`
+	Traffic       TrafficConfig       `mapstructure:"traffic"`
+	Cleanup       CleanupConfig       `mapstructure:"cleanup"`
+	KernelLog     KernelLogConfig     `mapstructure:"kernel_log"`
+	AuthLog       AuthLogConfig       `mapstructure:"auth_log"`
+	Fail2ban      Fail2banConfig      `mapstructure:"fail2ban"`
 }
 
 // BotConfig конфигурация бота
@@ -18,12 +29,51 @@ type BotConfig struct {
 	UpdateTimeout int     `mapstructure:"update_timeout"`
 }
 
+// NotificationChatID возвращает чат для уведомлений (первый из разрешенных)
+func (c BotConfig) NotificationChatID() int64 {
+	if len(c.AllowedChats) > 0 {
+		return c.AllowedChats[0]
+	}
+	return 0
+}
+
 // MonitoringConfig конфигурация мониторинга
 type MonitoringConfig struct {
-	CheckInterval   int `mapstructure:"check_interval"`
-	CPUThreshold    int `mapstructure:"cpu_threshold"`
-	MemoryThreshold int `mapstructure:"memory_threshold"`
-	DiskThreshold   int `mapstructure:"disk_threshold"`
+	CheckInterval        int `mapstructure:"check_interval"`
+	CPUThreshold         int `mapstructure:"cpu_threshold"`
+	MemoryThreshold      int `mapstructure:"memory_threshold"`
+	DiskThreshold        int `mapstructure:"disk_threshold"`
+	CPUClearThreshold    int `mapstructure:"cpu_clear_threshold"`
+	MemoryClearThreshold int `mapstructure:"memory_clear_threshold"`
+	DiskClearThreshold   int `mapstructure:"disk_clear_threshold"`
+	InodeThreshold       int `mapstructure:"inode_threshold"`
+	InodeClearThreshold  int `mapstructure:"inode_clear_threshold"`
+	AlertDuration        int `mapstructure:"alert_duration"`
+	RepeatInterval       int `mapstructure:"repeat_interval"`
+	// Rules заменяют глобальные пороги; если список пуст, правила строятся из порогов выше
+	Rules []AlertRuleConfig `mapstructure:"rules"`
+}
+
+// AlertRuleConfig правило алерта по метрике
+type AlertRuleConfig struct {
+	Name   string `mapstructure:"name"`
+	Metric string `mapstructure:"metric"`
+	// Selector шаблон точки монтирования или интерфейса (glob), пустой - все
+	Selector string   `mapstructure:"selector"`
+	Exclude  []string `mapstructure:"exclude"`
+	Operator string   `mapstructure:"operator"`
+	// Threshold одиночный порог с уровнем Severity; альтернатива Warning/Critical
+	Threshold *float64 `mapstructure:"threshold"`
+	Severity  string   `mapstructure:"severity"`
+	Warning   *float64 `mapstructure:"warning"`
+	Critical  *float64 `mapstructure:"critical"`
+	Clear     *float64 `mapstructure:"clear"`
+	// Duration и Repeat в секундах; если не заданы, используются alert_duration и repeat_interval
+	Duration *int    `mapstructure:"duration"`
+	Repeat   *int    `mapstructure:"repeat"`
+	Chats    []int64 `mapstructure:"chats"`
+	// Channels именованные каналы уведомлений; если заданы вместе с Chats, используются оба
+	Channels []string `mapstructure:"channels"`
 }
 
 // DockerConfig конфигурация Docker
@@ -32,6 +82,132 @@ type DockerConfig struct {
 	Timeout int    `mapstructure:"timeout"`
 }
 
+// StorageConfig конфигурация хранения данных бота
+type StorageConfig struct {
+	DataDir string `mapstructure:"data_dir"`
+}
+
+// MaintenanceConfig конфигурация обслуживания сервера
+type MaintenanceConfig struct {
+	RebootTime string `mapstructure:"reboot_time"`
+}
+
+// NotificationsConfig конфигурация маршрутизации уведомлений
+type NotificationsConfig struct {
+	Channels   map[string]ChannelConfig `mapstructure:"channels"`
+	Routes     []RouteConfig            `mapstructure:"routes"`
+	QuietHours QuietHoursConfig         `mapstructure:"quiet_hours"`
+	Escalation EscalationConfig         `mapstructure:"escalation"`
+}
+
+// ChannelConfig канал уведомлений: чат и, для групп с темами, идентификатор темы
+type ChannelConfig struct {
+	ChatID   int64 `mapstructure:"chat_id"`
+	ThreadID int   `mapstructure:"thread_id"`
+}
+
+// RouteConfig маршрут уведомлений; пустой список условий подходит под любое значение
+type RouteConfig struct {
+	Channels []string `mapstructure:"channels"`
+	Severity []string `mapstructure:"severity"`
+	Source   []string `mapstructure:"source"`
+}
+
+// QuietHoursConfig тихие часы, в которые доставляются только критичные уведомления
+type QuietHoursConfig struct {
+	Start string `mapstructure:"start"`
+	End   string `mapstructure:"end"`
+}
+
+// EscalationConfig эскалация неподтвержденных алертов
+type EscalationConfig struct {
+	Channel string `mapstructure:"channel"`
+	// After время в секундах, после которого неподтвержденный алерт эскалируется
+	After int `mapstructure:"after"`
+}
+
+// MetricsConfig конфигурация хранения истории метрик
+type MetricsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Interval период сбора метрик в секундах
+	Interval int `mapstructure:"interval"`
+	// RawRetention глубина хранения исходных значений в часах
+	RawRetention int `mapstructure:"raw_retention"`
+	// RollupInterval шаг усредненных значений в секундах
+	RollupInterval int `mapstructure:"rollup_interval"`
+	// RollupRetention глубина хранения усредненных значений в днях
+	RollupRetention int `mapstructure:"rollup_retention"`
+}
+
+// DigestConfig конфигурация периодических сводок
+type DigestConfig struct {
+	// DailyTime время ежедневной сводки (ЧЧ:ММ), пустое значение отключает её
+	DailyTime string `mapstructure:"daily_time"`
+	// WeeklyDay день недели еженедельной сводки (monday, tuesday, ...), пустое значение отключает её
+	WeeklyDay  string `mapstructure:"weekly_day"`
+	WeeklyTime string `mapstructure:"weekly_time"`
+	// Chats и Channels получатели сводки; если не заданы, используется чат уведомлений по умолчанию
+	Chats    []int64  `mapstructure:"chats"`
+	Channels []string `mapstructure:"channels"`
+}
+
+// ExporterConfig конфигурация HTTP эндпоинта метрик в формате Prometheus
+type ExporterConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Listen адрес HTTP сервера, например 127.0.0.1:9101
+	Listen string `mapstructure:"listen"`
+	Path   string `mapstructure:"path"`
+}
+
+// TrafficConfig конфигурация учета трафика за расчетный период
+type TrafficConfig struct {
+	// Interfaces учитываемые интерфейсы; пустой список означает все, кроме loopback
+	Interfaces []string `mapstructure:"interfaces"`
+	// Quota квота трафика на период в гигабайтах, 0 - без квоты
+	Quota float64 `mapstructure:"quota"`
+	// Direction учитываемое в квоте направление: both, rx или tx
+	Direction string `mapstructure:"direction"`
+	// ResetDay день месяца, с которого начинается расчетный период (1-28)
+	ResetDay int `mapstructure:"reset_day"`
+}
+
+// CleanupConfig конфигурация очистки диска
+type CleanupConfig struct {
+	// JournalSize размер в мегабайтах, до которого сокращается журнал systemd
+	JournalSize int `mapstructure:"journal_size"`
+	// LogAge возраст в днях, старше которого удаляются ротированные журналы
+	LogAge int `mapstructure:"log_age"`
+}
+
+// KernelLogConfig конфигурация слежения за журналом ядра
+type KernelLogConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Cooldown минимальный интервал в секундах между уведомлениями об однотипных событиях
+	Cooldown int `mapstructure:"cooldown"`
+}
+
+// AuthLogConfig конфигурация уведомлений о входах по SSH и использовании sudo
+type AuthLogConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// AllowedUsers и AllowedIPs (адреса или сети CIDR) описывают ожидаемые входы, о которых не уведомлять;
+	// если заданы оба списка, вход должен соответствовать обоим
+	AllowedUsers []string `mapstructure:"allowed_users"`
+	AllowedIPs   []string `mapstructure:"allowed_ips"`
+	// Sudo включает уведомления о командах sudo пользователей не из AllowedUsers
+	Sudo bool `mapstructure:"sudo"`
+	// FailedThreshold количество неудачных попыток входа с одного адреса за FailedWindow секунд для уведомления
+	FailedThreshold int `mapstructure:"failed_threshold"`
+	FailedWindow    int `mapstructure:"failed_window"`
+	// Cooldown минимальный интервал в секундах между уведомлениями о попытках с одного адреса и одинаковых командах sudo
+	Cooldown int `mapstructure:"cooldown"`
+}
+
+// Fail2banConfig конфигурация интеграции с fail2ban
+type Fail2banConfig struct {
+	// Digest включает раздел о блокировках fail2ban в периодических сводках
+	Digest bool `mapstructure:"digest"`
+}
+
 // Load загружает конфигурацию из файла
 func Load() (*Config, error) {
 	var config Config
@@ -0,0 +1,66 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+)
+
+// Clock время суток в формате ЧЧ:ММ
+type Clock struct {
+	Hour   int
+	Minute int
+}
+
+// ParseClock разбирает время суток в формате ЧЧ:ММ
+func ParseClock(s string) (Clock, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return Clock{}, fmt.Errorf("некорректное время %q, ожидается формат ЧЧ:ММ", s)
+	}
+	return Clock{Hour: t.Hour(), Minute: t.Minute()}, nil
+}
+
+// Next возвращает ближайший после now момент с этим временем суток
+func (c Clock) Next(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), c.Hour, c.Minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Within проверяет, попадает ли время суток t в интервал [start, end)
+// Интервал может переходить через полночь, например 23:00-07:00
+func Within(start, end Clock, t time.Time) bool {
+	minutes := t.Hour()*60 + t.Minute()
+	from := start.Hour*60 + start.Minute
+	to := end.Hour*60 + end.Minute
+
+	if from <= to {
+		return minutes >= from && minutes < to
+	}
+	return minutes >= from || minutes < to
+}
+
+// String возвращает время в формате ЧЧ:ММ
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", c.Hour, c.Minute)
+}
+
+// FormatDuration форматирует длительность в виде "1д 2ч 3м"
+func FormatDuration(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dд %dч %dм", days, hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dч %dм", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dм", minutes)
+	default:
+		return fmt.Sprintf("%dс", int(d.Seconds()))
+	}
+}